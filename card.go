@@ -1,6 +1,7 @@
 package tienlen_bot
 
 import (
+	"fmt"
 	"math/rand"
 	"sort"
 	"strings"
@@ -125,9 +126,22 @@ func (c *Card) equals(card *Card) bool {
 	return c.rank == card.rank && c.suit == card.suit
 }
 
+// ParseCard parses a card like "10♥" or "A♠", it panics on invalid input
 func ParseCard(s string) *Card {
+	c, err := TryParseCard(s)
+	if err != nil {
+		panic(err.Error())
+	}
+	return c
+}
+
+// TryParseCard works like ParseCard but returns an error wrapping ErrInvalidCard on invalid input
+func TryParseCard(s string) (*Card, error) {
 	c := &Card{}
 	var rank, suit string
+	if len(s) < 2 {
+		return nil, fmt.Errorf("%w string %s", ErrInvalidCard, s)
+	}
 	if s[:2] == "10" {
 		rank = "10"
 		suit = s[2:]
@@ -147,7 +161,7 @@ func ParseCard(s string) *Card {
 	case "♠":
 		c.suit = Spade
 	default:
-		panic("invalid card string " + s)
+		return nil, fmt.Errorf("%w string %s", ErrInvalidCard, s)
 	}
 	switch rank {
 	case "a":
@@ -177,18 +191,32 @@ func ParseCard(s string) *Card {
 	case "k":
 		c.rank = King
 	default:
-		panic("invalid card string " + s)
+		return nil, fmt.Errorf("%w string %s", ErrInvalidCard, s)
 	}
-	return c
+	return c, nil
 }
 
+// ParseCards parses a comma separated list of cards, it panics on invalid input
 func ParseCards(s string) []*Card {
+	cards, err := TryParseCards(s)
+	if err != nil {
+		panic(err.Error())
+	}
+	return cards
+}
+
+// TryParseCards works like ParseCards but returns an error wrapping ErrInvalidCard on invalid input
+func TryParseCards(s string) ([]*Card, error) {
 	list := strings.Split(strings.ReplaceAll(s, " ", ""), ",")
 	cards := make([]*Card, len(list))
 	for i := 0; i < len(cards); i++ {
-		cards[i] = ParseCard(list[i])
+		card, err := TryParseCard(list[i])
+		if err != nil {
+			return nil, err
+		}
+		cards[i] = card
 	}
-	return cards
+	return cards, nil
 }
 
 func SortCard(cards []*Card) []*Card {
@@ -206,23 +234,49 @@ func ifThen (condition bool, a interface{}, b interface{}) interface{} {
 }
 
 type Deck struct {
-	cards []*Card
+	cards  []*Card
+	random *rand.Rand
 }
 
+// NewDeck creates a deck shuffled from the clock
 func NewDeck() *Deck {
-	d := &Deck{
-		cards: make([]*Card, 52),
+	return NewDeckWithSeed(0)
+}
+
+// NewDeckWithSeed creates a deck dealing the same cards for the same seed,
+// seed 0 means a seed taken from the clock
+func NewDeckWithSeed(seed int64) *Deck {
+	return NewDeckWithRandom(newRandom(seed))
+}
+
+// newRandom creates a source of randomness for seed, seed 0 means a seed taken from the clock
+func newRandom(seed int64) *rand.Rand {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
 	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// NewDeckWithRandom creates a deck drawing its cards with random
+func NewDeckWithRandom(random *rand.Rand) *Deck {
+	return &Deck{
+		cards:  newCards(),
+		random: random,
+	}
+}
+
+// newCards trả về 52 lá bài sắp xếp từ nhỏ đến lớn
+func newCards() []*Card {
+	cards := make([]*Card, 52)
 	for rank := Three; rank <= Two; rank++ {
 		for suit := Spade; suit <= Heart; suit++ {
-			d.cards[int(rank) * 4 + int(suit)] = &Card{
+			cards[int(rank) * 4 + int(suit)] = &Card{
 				rank: rank,
 				suit: suit,
 			}
 		}
 	}
-	SortCard(d.cards)
-	return d
+	return SortCard(cards)
 }
 
 func (d *Deck) randomCards(numberOfCards int) []*Card {
@@ -231,8 +285,7 @@ func (d *Deck) randomCards(numberOfCards int) []*Card {
 	}
 	cards := []*Card{}
 	for i := 0; i < numberOfCards; i++ {
-		rand.Seed(time.Now().UnixNano())
-		index := rand.Intn(len(d.cards))
+		index := d.random.Intn(len(d.cards))
 		cards = append(cards, d.cards[index])
 		d.cards = append(d.cards[:index], d.cards[index+1:]...)
 	}
@@ -0,0 +1,133 @@
+package tienlen_bot
+
+// CardTracker counts the cards from the point of view of one seat: the cards in its hand,
+// the cards already played on the table and the unseen cards, which are the only cards
+// the opponents can hold. It only uses public information and the seat's own cards.
+type CardTracker struct {
+	hand   CardSet
+	played CardSet
+	rules  RuleSet
+}
+
+// NewCardTracker creates a tracker for a seat holding hand after played were played.
+// A nil rules means the Cambodian rules.
+func NewCardTracker(hand []*Card, played []*Card, rules RuleSet) *CardTracker {
+	if isNil(rules) {
+		rules = defaultRules
+	}
+	return &CardTracker{
+		hand:   NewCardSet(hand...),
+		played: NewCardSet(played...),
+		rules:  rules,
+	}
+}
+
+// Record marks cards as played, the cards leave the seat's hand when they were in it
+func (t *CardTracker) Record(cards []*Card) {
+	set := NewCardSet(cards...)
+	t.hand = t.hand.Difference(set)
+	t.played = t.played.Union(set)
+}
+
+// Hand returns the cards still in the seat's hand
+func (t *CardTracker) Hand() CardSet {
+	return t.hand
+}
+
+// Played returns the cards already played
+func (t *CardTracker) Played() CardSet {
+	return t.played
+}
+
+// Unseen returns the cards that are neither in the seat's hand nor played
+func (t *CardTracker) Unseen() CardSet {
+	return FullDeck.Difference(t.hand).Difference(t.played)
+}
+
+// CountUnseenRank returns the number of unseen cards with the given rank
+func (t *CardTracker) CountUnseenRank(rank Rank) int {
+	return t.Unseen().CountRank(rank)
+}
+
+// UnseenTwos returns the number of unseen 2s
+func (t *CardTracker) UnseenTwos() int {
+	return t.CountUnseenRank(Two)
+}
+
+// IsHighestOutstanding reports whether no combination of the same kind made from the unseen cards
+// defeats combination, a sequence is only compared with sequences of its length.
+// Quads and consecutive pairs chopping it are not taken into account, see CanUnseenDefeat.
+func (t *CardTracker) IsHighestOutstanding(combination Combination) bool {
+	return !t.unseenOfKindDefeats(combination, combination.Kind())
+}
+
+// CanUnseenDefeat reports whether some combination made from the unseen cards defeats combination,
+// chopping included
+func (t *CardTracker) CanUnseenDefeat(combination Combination) bool {
+	if t.unseenOfKindDefeats(combination, combination.Kind()) {
+		return true
+	}
+	for _, kind := range []CombinationKind{
+		CombinationThreeConsecutivePairs,
+		CombinationQuads,
+		CombinationFourConsecutivePairs,
+	} {
+		if kind != combination.Kind() && t.unseenOfKindDefeats(combination, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// unseenOfKindDefeats kiểm tra có bộ loại kind từ các lá chưa lộ diện chặt được target không
+func (t *CardTracker) unseenOfKindDefeats(target Combination, kind CombinationKind) bool {
+	if kind == CombinationPass {
+		return false
+	}
+	unseen := t.Unseen()
+	if kind == CombinationSequence {
+		return t.unseenSequenceDefeats(unseen, target.(*Sequence))
+	}
+	return !eachOfKind(unseen, kind, 0, func(combination Combination) bool {
+		return !t.rules.Defeats(combination, target)
+	})
+}
+
+// unseenSequenceDefeats kiểm tra các sảnh cùng độ dài với target. Số sảnh có thể rất lớn nên chỉ thử
+// với mỗi lá cao nhất: sảnh đồng chất, sảnh theo chất của target và một sảnh bất kỳ,
+// vì luật chỉ so rank, lá cao nhất, sảnh có đồng chất không và có theo chất target không
+func (t *CardTracker) unseenSequenceDefeats(unseen CardSet, target *Sequence) bool {
+	length := len(target.cardList)
+	for start := Three; start+Rank(length)-1 <= Ace; start++ {
+		top := start + Rank(length) - 1
+		for _, card := range unseen.Intersect(RankMask(top)).Cards() {
+			candidates := [][]*Card{
+				make([]*Card, 0, length),
+				make([]*Card, 0, length),
+				make([]*Card, 0, length),
+			}
+			for rank := start; rank < top; rank++ {
+				i := int(rank - start)
+				if c := canonicalCard(rank, card.suit); unseen.Contains(c) {
+					candidates[0] = append(candidates[0], c)
+				}
+				if c := canonicalCard(rank, target.cardList[i].suit); unseen.Contains(c) {
+					candidates[1] = append(candidates[1], c)
+				}
+				if set := unseen.Intersect(RankMask(rank)); !set.IsEmpty() {
+					candidates[2] = append(candidates[2], set.Smallest())
+				}
+			}
+			for _, cards := range candidates {
+				if len(cards) != length-1 {
+					continue
+				}
+				cards = append(cards, card)
+				if t.rules.Defeats(NewSequence(cards), target) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package tienlen_bot
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// CardSet is a set of cards stored in the 52 low bits of an uint64,
+// the bit of a card is rank*4 + suit so the cards of a set are ordered like compareCard
+type CardSet uint64
+
+// FullDeck is the set of the 52 cards
+const FullDeck CardSet = 1<<52 - 1
+
+// canonicalCards là 52 lá bài dùng chung, lá ở vị trí bit của nó
+var canonicalCards = func() [52]*Card {
+	var cards [52]*Card
+	for rank := Three; rank <= Two; rank++ {
+		for suit := Spade; suit <= Heart; suit++ {
+			cards[int(rank)*4+int(suit)] = &Card{rank: rank, suit: suit}
+		}
+	}
+	return cards
+}()
+
+// canonicalCard returns the shared instance of the card with rank and suit
+func canonicalCard(rank Rank, suit Suit) *Card {
+	return canonicalCards[int(rank)*4+int(suit)]
+}
+
+func cardBit(card *Card) CardSet {
+	return CardSet(1) << (uint(card.rank)*4 + uint(card.suit))
+}
+
+func NewCardSet(cards ...*Card) CardSet {
+	var s CardSet
+	for _, card := range cards {
+		s |= cardBit(card)
+	}
+	return s
+}
+
+// RankMask returns the set of the four cards of rank
+func RankMask(rank Rank) CardSet {
+	return CardSet(0xF) << (uint(rank) * 4)
+}
+
+// SuitMask returns the set of the thirteen cards of suit
+func SuitMask(suit Suit) CardSet {
+	return CardSet(0x1111111111111) << uint(suit)
+}
+
+func (s CardSet) Add(card *Card) CardSet {
+	return s | cardBit(card)
+}
+
+func (s CardSet) Remove(card *Card) CardSet {
+	return s &^ cardBit(card)
+}
+
+func (s CardSet) Contains(card *Card) bool {
+	return s&cardBit(card) != 0
+}
+
+func (s CardSet) Union(o CardSet) CardSet {
+	return s | o
+}
+
+func (s CardSet) Intersect(o CardSet) CardSet {
+	return s & o
+}
+
+func (s CardSet) Difference(o CardSet) CardSet {
+	return s &^ o
+}
+
+// ContainsAll reports whether every card of o is in s
+func (s CardSet) ContainsAll(o CardSet) bool {
+	return s&o == o
+}
+
+// Overlaps reports whether s and o have at least one card in common
+func (s CardSet) Overlaps(o CardSet) bool {
+	return s&o != 0
+}
+
+func (s CardSet) Len() int {
+	return bits.OnesCount64(uint64(s))
+}
+
+func (s CardSet) IsEmpty() bool {
+	return s == 0
+}
+
+// CountRank returns the number of cards of rank in s
+func (s CardSet) CountRank(rank Rank) int {
+	return s.Intersect(RankMask(rank)).Len()
+}
+
+// Smallest returns the smallest card of s, nil if s is empty
+func (s CardSet) Smallest() *Card {
+	if s == 0 {
+		return nil
+	}
+	return canonicalCards[bits.TrailingZeros64(uint64(s))]
+}
+
+// Cards returns the cards of s from the smallest to the biggest
+func (s CardSet) Cards() []*Card {
+	cards := make([]*Card, 0, s.Len())
+	for s != 0 {
+		i := bits.TrailingZeros64(uint64(s))
+		cards = append(cards, canonicalCards[i])
+		s &= s - 1
+	}
+	return cards
+}
+
+func (s CardSet) String() string {
+	cards := s.Cards()
+	list := make([]string, len(cards))
+	for i := range cards {
+		list[i] = cards[i].String()
+	}
+	return "{" + strings.Join(list, " ") + "}"
+}
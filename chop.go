@@ -0,0 +1,53 @@
+package tienlen_bot
+
+// ChopEvent is a chop (chặt): Receiver dealt Combination on top of the 2s or the bomb Target
+// of Payer, and Payer pays Amount points to Receiver right away.
+// When a chop is chopped again inside the same round, the amounts of the chain roll onto the last victim.
+type ChopEvent struct {
+	Payer       int
+	Receiver    int
+	Amount      int
+	Combination Combination
+	Target      Combination
+}
+
+// isBomb trả về true nếu combination là hàng chặt được 2
+func isBomb(combination Combination) bool {
+	switch combination.Kind() {
+	case CombinationQuads, CombinationThreeConsecutivePairs, CombinationFourConsecutivePairs:
+		return true
+	}
+	return false
+}
+
+// ChopValue returns the points paid when combination is chopped, 0 if it can not be chopped
+func (t *PenaltyTable) ChopValue(combination Combination) int {
+	switch combination.Kind() {
+	case CombinationSingle, CombinationDubs:
+		value := 0
+		for _, card := range combination.Cards() {
+			if card.rank != Two {
+				return 0
+			}
+			value += ifThen(card.suit < Diamond, t.Black2, t.Red2).(int)
+		}
+		return value
+	case CombinationThreeConsecutivePairs:
+		return t.ThreeConsecutivePairs
+	case CombinationFourConsecutivePairs:
+		return t.FourConsecutivePairs
+	case CombinationQuads:
+		return t.Quads
+	}
+	return 0
+}
+
+// chopScores trả về tổng điểm chặt được (dương) hoặc bị chặt (âm) của từng người chơi
+func chopScores(events []ChopEvent, maxNumberOfPlayers int) []int {
+	scores := make([]int, maxNumberOfPlayers)
+	for _, event := range events {
+		scores[event.Payer] -= event.Amount
+		scores[event.Receiver] += event.Amount
+	}
+	return scores
+}
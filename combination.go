@@ -1,7 +1,7 @@
 package tienlen_bot
 
 import (
-	"errors"
+	"fmt"
 	"sort"
 )
 
@@ -19,11 +19,35 @@ const (
 	CombinationPass
 )
 
+func (k CombinationKind) String() string {
+	switch k {
+	case CombinationSingle:
+		return "single"
+	case CombinationDubs:
+		return "dubs"
+	case CombinationTrips:
+		return "trips"
+	case CombinationQuads:
+		return "quads"
+	case CombinationSequence:
+		return "sequence"
+	case CombinationTwoConsecutivePairs:
+		return "two consecutive pairs"
+	case CombinationThreeConsecutivePairs:
+		return "three consecutive pairs"
+	case CombinationFourConsecutivePairs:
+		return "four consecutive pairs"
+	case CombinationPass:
+		return "pass"
+	default:
+		return "undefined"
+	}
+}
+
 type Combination interface {
 	Kind() CombinationKind
 	Equals(combination Combination) bool
 	Cards() []*Card
-	Defeats(combination Combination) bool
 	Copy() Combination
 	String() string
 }
@@ -48,83 +72,102 @@ func containsRank(cards []*Card, rank Rank) bool {
 
 func ParseCombination(cards []*Card, kind CombinationKind) (Combination, error) {
 	cards = SortCard(cards)
+	for i := 0; i+1 < len(cards); i++ {
+		if cards[i].equals(cards[i+1]) {
+			return nil, fmt.Errorf("%w duplicate card %s", ErrInvalidCombination, cards[i])
+		}
+	}
 	switch kind {
 	case CombinationSingle:
 		if len(cards) != 1 {
-			return nil, errors.New("invalid single card")
+			return nil, fmt.Errorf("%w single card", ErrInvalidCombination)
 		}
 		return NewSingleCard(cards[0]), nil
 	case CombinationDubs:
 		if len(cards) != 2 || !isDubs(cards[0], cards[1]) {
-			return nil, errors.New("invalid dubs")
+			return nil, fmt.Errorf("%w dubs", ErrInvalidCombination)
 		}
 		return NewDubs(cards[0], cards[1]), nil
 	case CombinationTrips:
 		if len(cards) != 3 || !isTrips(cards[0], cards[1], cards[2]) {
-			return nil, errors.New("invalid trips")
+			return nil, fmt.Errorf("%w trips", ErrInvalidCombination)
 		}
 		return NewTrips(cards[0], cards[1], cards[2]), nil
 	case CombinationQuads:
 		if len(cards) != 4 || !isQuads(cards[0], cards[1], cards[2], cards[3]) {
-			return nil, errors.New("invalid quads")
+			return nil, fmt.Errorf("%w quads", ErrInvalidCombination)
 		}
 		return NewQuads(cards[0], cards[1], cards[2], cards[3]), nil
 	case CombinationSequence:
 		if !isSequence(cards) {
-			return nil, errors.New("invalid sequence")
+			return nil, fmt.Errorf("%w sequence", ErrInvalidCombination)
 		}
 		return NewSequence(cards), nil
 	case CombinationTwoConsecutivePairs:
 		if len(cards) != 4 || !isDubs(cards[0], cards[1]) || !isDubs(cards[2], cards[3]) {
-			return nil, errors.New("invalid two consecutive pairs")
+			return nil, fmt.Errorf("%w two consecutive pairs", ErrInvalidCombination)
 		}
 		dubs1 := NewDubs(cards[0], cards[1])
 		dubs2 := NewDubs(cards[2], cards[3])
 		if !isTwoConsecutivePairs(dubs1, dubs2) {
-			return nil, errors.New("invalid two consecutive pairs")
+			return nil, fmt.Errorf("%w two consecutive pairs", ErrInvalidCombination)
 		}
 		return NewTwoConsecutivePairs(dubs1, dubs2), nil
 	case CombinationThreeConsecutivePairs:
 		if len(cards) != 6 || !isDubs(cards[0], cards[1]) || !isDubs(cards[2], cards[3]) ||
 			!isDubs(cards[4], cards[5]) {
-			return nil, errors.New("invalid three consecutive pairs")
+			return nil, fmt.Errorf("%w three consecutive pairs", ErrInvalidCombination)
 		}
 		dubs1 := NewDubs(cards[0], cards[1])
 		dubs2 := NewDubs(cards[2], cards[3])
 		dubs3 := NewDubs(cards[4], cards[5])
 		if !isThreeConsecutivePairs(dubs1, dubs2, dubs3) {
-			return nil, errors.New("invalid three consecutive pairs")
+			return nil, fmt.Errorf("%w three consecutive pairs", ErrInvalidCombination)
 		}
 		return NewThreeConsecutivePairs(dubs1, dubs2, dubs3), nil
 	case CombinationFourConsecutivePairs:
 		if len(cards) != 8 || !isDubs(cards[0], cards[1]) || !isDubs(cards[2], cards[3]) ||
 			!isDubs(cards[4], cards[5]) || !isDubs(cards[6], cards[7]) {
-			return nil, errors.New("invalid four consecutive pairs")
+			return nil, fmt.Errorf("%w four consecutive pairs", ErrInvalidCombination)
 		}
 		dubs1 := NewDubs(cards[0], cards[1])
 		dubs2 := NewDubs(cards[2], cards[3])
 		dubs3 := NewDubs(cards[4], cards[5])
 		dubs4 := NewDubs(cards[6], cards[7])
 		if !isFourConsecutivePairs(dubs1, dubs2, dubs3, dubs4) {
-			return nil, errors.New("invalid four consecutive pairs")
+			return nil, fmt.Errorf("%w four consecutive pairs", ErrInvalidCombination)
 		}
 		return NewFourConsecutivePairs(dubs1, dubs2, dubs3, dubs4), nil
 	case CombinationPass:
 		return NewPass(), nil
 	default:
-		return nil, errors.New("invalid combination Kind")
+		return nil, fmt.Errorf("%w kind", ErrInvalidCombination)
 	}
 }
 
+// ClassifyCards returns every combination the cards can be played as, without knowing its kind.
+// An error wrapping ErrInvalidCombination is returned when the cards form no combination.
+func ClassifyCards(cards []*Card) ([]Combination, error) {
+	combinations := []Combination{}
+	for kind := CombinationSingle; kind < CombinationPass; kind++ {
+		list := make([]*Card, len(cards))
+		copy(list, cards)
+		if combination, err := ParseCombination(list, kind); err == nil {
+			combinations = append(combinations, combination)
+		}
+	}
+	if len(combinations) == 0 {
+		return nil, fmt.Errorf("%w cards %v", ErrInvalidCombination, cards)
+	}
+	return combinations, nil
+}
+
 // GetDubs get all dubs in card list
 func GetDubs(cards []*Card) []*Dubs {
 	var dubs []*Dubs
-	for i := 0; i < len(cards); i++ {
-		for j := i + 1; j < len(cards); j++ {
-			if isDubs(cards[i], cards[j]) {
-				dubs = append(dubs, NewDubs(cards[i], cards[j]))
-			}
-		}
+	set := NewCardSet(cards...)
+	for rank := Three; rank <= Two; rank++ {
+		dubs = append(dubs, dubsOfRank(set, rank)...)
 	}
 	sort.Slice(dubs, func(i, j int) bool {
 		return compareDubs(dubs[i], dubs[j]) < 0
@@ -135,26 +178,18 @@ func GetDubs(cards []*Card) []*Dubs {
 // GetTrips get all trips in card list
 func GetTrips(cards []*Card) []*Trips {
 	trips := []*Trips{}
-	count := make([]int, 13)
-	for _, card := range cards {
-		count[card.rank]++
-	}
+	set := NewCardSet(cards...)
 	for rank := Three; rank <= Two; rank++ {
-		if count[rank] < 3 {
+		list := set.Intersect(RankMask(rank)).Cards()
+		if len(list) < 3 {
 			continue
 		}
-		if count[rank] == 3 {
-			list := getAllCardsWithRank(cards, rank)
-			trips = append(trips, NewTrips(list[0], list[1], list[2]))
-		} else if count[rank] == 4 {
-			card1 := &Card{rank: rank, suit: Spade}
-			card2 := &Card{rank: rank, suit: Club}
-			card3 := &Card{rank: rank, suit: Diamond}
-			card4 := &Card{rank: rank, suit: Heart}
-			trips = append(trips, NewTrips(card1, card2, card3))
-			trips = append(trips, NewTrips(card1, card2, card4))
-			trips = append(trips, NewTrips(card1, card3, card4))
-			trips = append(trips, NewTrips(card2, card3, card4))
+		for i := 0; i < len(list); i++ {
+			for j := i + 1; j < len(list); j++ {
+				for t := j + 1; t < len(list); t++ {
+					trips = append(trips, NewTrips(list[i], list[j], list[t]))
+				}
+			}
 		}
 	}
 	sort.Slice(trips, func(i, j int) bool {
@@ -166,64 +201,42 @@ func GetTrips(cards []*Card) []*Trips {
 // GetQuads get all quads in card list
 func GetQuads(cards []*Card) []*Quads {
 	quads := []*Quads{}
-	count := make([]int, 13)
-	for _, card := range cards {
-		count[card.rank]++
-	}
+	set := NewCardSet(cards...)
 	for rank := Three; rank <= Two; rank++ {
-		if count[rank] == 4 {
-			quads = append(quads, NewQuads(&Card{rank: rank, suit: Spade}, &Card{rank: rank,suit: Club},
-			&Card{rank: rank, suit: Diamond}, &Card{rank: rank, suit: Heart}))
+		if set.CountRank(rank) == 4 {
+			list := RankMask(rank).Cards()
+			quads = append(quads, NewQuads(list[0], list[1], list[2], list[3]))
 		}
 	}
-	sort.Slice(quads, func(i, j int) bool {
-		return compareQuads(quads[i], quads[j]) < 0
-	})
 	return quads
 }
 
-// GetSequence get all sequence in card list
+// GetSequence get all sequence in card list.
+// Every suit variant is returned: with 3♠,3♥,4♠,5♠ both 3♠,4♠,5♠ and 3♥,4♠,5♠ are sequences.
 func GetSequence(cards []*Card) []*Sequence {
 	sequences := []*Sequence{}
-	checkList := make([][]*Card, 13)
-	for i := 0; i < len(cards); i++ {
-		list := checkList[cards[i].rank]
-		if list == nil {
-			checkList[cards[i].rank] = []*Card{cards[i]}
-		} else {
-			checkList[cards[i].rank] = append(list, cards[i])
-		}
-	}
-	for i := Three; i <= Queen; i++ {
-		list := checkList[i]
-		if list == nil {
-			continue
-		}
-		var lastSequenceList [][]*Card
-		if l := checkList[i + 1]; l == nil {
-			continue
-		} else {
-			lastSequenceList = make([][]*Card, len(list))
-			for j := 0; j < len(list); j++ {
-				for t := 0; t < len(l); t++ {
-					lastSequenceList[j] = []*Card{list[j], l[t]}
-				}
-			}
-		}
-		for j := i + 2; j <= Ace; j++ {
-			l := checkList[j]
-			if l == nil {
+	set := NewCardSet(cards...)
+	for start := Three; start <= Queen; start++ {
+		// các dãy đang xây bắt đầu từ start, mỗi dãy là một slice riêng để không ghi đè lên nhau
+		prefixes := [][]*Card{{}}
+		for rank := start; rank <= Ace; rank++ {
+			list := set.Intersect(RankMask(rank)).Cards()
+			if len(list) == 0 {
 				break
 			}
-			currentSequenceList := [][]*Card{}
-			for t := 0; t < len(l); t++ {
-				for r := 0; r < len(lastSequenceList); r++ {
-					sequence := append(lastSequenceList[r], l[t])
-					sequences = append(sequences, NewSequence(sequence))
-					currentSequenceList = append(currentSequenceList, sequence)
+			next := make([][]*Card, 0, len(prefixes)*len(list))
+			for _, prefix := range prefixes {
+				for _, card := range list {
+					sequence := make([]*Card, len(prefix)+1)
+					copy(sequence, prefix)
+					sequence[len(prefix)] = card
+					next = append(next, sequence)
+					if len(sequence) >= 3 {
+						sequences = append(sequences, NewSequence(sequence))
+					}
 				}
 			}
-			lastSequenceList = currentSequenceList
+			prefixes = next
 		}
 	}
 	sort.Slice(sequences, func(i, j int) bool {
@@ -235,15 +248,10 @@ func GetSequence(cards []*Card) []*Sequence {
 // GetTwoConsecutivePairs get all two consecutive pairs in card list
 func GetTwoConsecutivePairs(cards []*Card) []*TwoConsecutivePairs {
 	var pairs []*TwoConsecutivePairs
-	dubs := GetDubs(cards)
-	if len(dubs) < 2 {
-		return pairs
-	}
-	for i := 0; i < len(dubs) - 1; i++ {
-		for j := i + 1; j < len(dubs); j++ {
-			if isTwoConsecutivePairs(dubs[i], dubs[j]) {
-				pairs = append(pairs, NewTwoConsecutivePairs(dubs[i], dubs[j]))
-			}
+	set := NewCardSet(cards...)
+	for rank := Three; rank+1 <= Ace; rank++ {
+		for _, list := range consecutiveDubs(set, rank, 2) {
+			pairs = append(pairs, NewTwoConsecutivePairs(list[0], list[1]))
 		}
 	}
 	sort.Slice(pairs, func(i, j int) bool {
@@ -254,17 +262,10 @@ func GetTwoConsecutivePairs(cards []*Card) []*TwoConsecutivePairs {
 
 func GetThreeConsecutivePairs(cards []*Card) []*ThreeConsecutivePairs {
 	var pairs []*ThreeConsecutivePairs
-	dubs := GetDubs(cards)
-	if len(dubs) < 3 {
-		return pairs
-	}
-	for i := 0; i < len(dubs) - 2; i++ {
-		for j := i + 1; j < len(dubs) - 1; j++ {
-			for t := j + 1; t < len(dubs); t++ {
-				if isThreeConsecutivePairs(dubs[i], dubs[j], dubs[t]) {
-					pairs = append(pairs, NewThreeConsecutivePairs(dubs[i], dubs[j], dubs[t]))
-				}
-			}
+	set := NewCardSet(cards...)
+	for rank := Three; rank+2 <= Ace; rank++ {
+		for _, list := range consecutiveDubs(set, rank, 3) {
+			pairs = append(pairs, NewThreeConsecutivePairs(list[0], list[1], list[2]))
 		}
 	}
 	sort.Slice(pairs, func(i, j int) bool {
@@ -275,19 +276,10 @@ func GetThreeConsecutivePairs(cards []*Card) []*ThreeConsecutivePairs {
 
 func GetFourConsecutivePairs(cards []*Card) []*FourConsecutivePairs {
 	var pairs []*FourConsecutivePairs
-	dubs := GetDubs(cards)
-	if len(dubs) < 4 {
-		return pairs
-	}
-	for i := 0; i < len(dubs) - 3; i++ {
-		for j := i + 1; j < len(dubs) - 2; j++ {
-			for t := j + 1; t < len(dubs) - 1; t++ {
-				for k := t + 1; k < len(dubs); k++ {
-					if isFourConsecutivePairs(dubs[i], dubs[j], dubs[t], dubs[k]) {
-						pairs = append(pairs, NewFourConsecutivePairs(dubs[i], dubs[j], dubs[t], dubs[k]))
-					}
-				}
-			}
+	set := NewCardSet(cards...)
+	for rank := Three; rank+3 <= Ace; rank++ {
+		for _, list := range consecutiveDubs(set, rank, 4) {
+			pairs = append(pairs, NewFourConsecutivePairs(list[0], list[1], list[2], list[3]))
 		}
 	}
 	sort.Slice(pairs, func(i, j int) bool {
@@ -296,6 +288,46 @@ func GetFourConsecutivePairs(cards []*Card) []*FourConsecutivePairs {
 	return pairs
 }
 
+// dubsOfRank trả về tất cả các đôi có rank trong set
+func dubsOfRank(set CardSet, rank Rank) []*Dubs {
+	var dubs []*Dubs
+	list := set.Intersect(RankMask(rank)).Cards()
+	for i := 0; i < len(list); i++ {
+		for j := i + 1; j < len(list); j++ {
+			dubs = append(dubs, NewDubs(list[i], list[j]))
+		}
+	}
+	return dubs
+}
+
+// consecutiveDubs trả về mọi cách chọn length đôi liên tiếp bắt đầu từ rank
+func consecutiveDubs(set CardSet, rank Rank, length int) [][]*Dubs {
+	// kiểm tra số lá trước để không cấp phát khi không có đôi thông nào
+	for r := rank; r < rank+Rank(length); r++ {
+		if set.CountRank(r) < 2 {
+			return nil
+		}
+	}
+	lists := [][]*Dubs{{}}
+	for r := rank; r < rank+Rank(length); r++ {
+		dubs := dubsOfRank(set, r)
+		if len(dubs) == 0 {
+			return nil
+		}
+		next := make([][]*Dubs, 0, len(lists)*len(dubs))
+		for _, list := range lists {
+			for _, d := range dubs {
+				l := make([]*Dubs, len(list)+1)
+				copy(l, list)
+				l[len(list)] = d
+				next = append(next, l)
+			}
+		}
+		lists = next
+	}
+	return lists
+}
+
 func getAllCardsWithRank(cards []*Card, rank Rank) []*Card {
 	var list []*Card
 	for i := 0; i < len(cards); i++ {
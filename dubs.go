@@ -39,23 +39,6 @@ func (d *Dubs) Cards() []*Card {
 	return []*Card{d.card1, d.card2}
 }
 
-func (d *Dubs) Defeats(combination Combination) bool {
-	if combination.Kind() != CombinationDubs {
-		return false
-	}
-	dub := combination.(*Dubs)
-	if d.rank > dub.rank {
-		return true
-	}
-	if d.rank < dub.rank {
-		return false
-	}
-	if d.rank >= Six {
-		return d.maxSuit > dub.maxSuit
-	}
-	return true
-}
-
 func (d *Dubs) Copy() Combination {
 	return NewDubs(d.card1, d.card2)
 }
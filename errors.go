@@ -0,0 +1,58 @@
+package tienlen_bot
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrGameEnded                         = errors.New("game has ended")
+	ErrGameNotEnded                      = errors.New("game has not ended")
+	ErrNotYourTurn                       = errors.New("not your turn")
+	ErrCombinationNotInHand              = errors.New("combination is not in hand")
+	ErrDoesNotDefeat                     = errors.New("combination does not defeat the last dealt combination")
+	ErrLeaderCannotPass                  = errors.New("leader can not pass")
+	ErrFirstPlayMustContainThreeOfSpades = errors.New("first play must contain 3♠")
+	ErrInvalidCard                       = errors.New("invalid card")
+	ErrInvalidCombination                = errors.New("invalid combination")
+	ErrMatchEnded                        = errors.New("match has ended")
+	ErrHandInProgress                    = errors.New("hand is still being played")
+	ErrInvalidHistory                    = errors.New("invalid history")
+	ErrNothingToUndo                     = errors.New("nothing to undo")
+	ErrNothingToRedo                     = errors.New("nothing to redo")
+	ErrInvalidPosition                   = errors.New("invalid position")
+	ErrInvalidSnapshot                   = errors.New("invalid snapshot")
+)
+
+// MoveError is returned when a player can not play a combination.
+// Err is one of the Err* values above and can be checked with errors.Is,
+// Reason explains it to a player when it is not empty.
+type MoveError struct {
+	PlayerIndex int
+	Combination Combination
+	Err         error
+	Reason      string
+}
+
+func newMoveError(playerIndex int, combination Combination, err error) *MoveError {
+	return &MoveError{
+		PlayerIndex: playerIndex,
+		Combination: combination,
+		Err:         err,
+	}
+}
+
+func (e *MoveError) Error() string {
+	reason := e.Err.Error()
+	if e.Reason != "" {
+		reason = e.Reason
+	}
+	if isNil(e.Combination) {
+		return fmt.Sprintf("player %d: %s", e.PlayerIndex, reason)
+	}
+	return fmt.Sprintf("player %d can not play %s: %s", e.PlayerIndex, e.Combination, reason)
+}
+
+func (e *MoveError) Unwrap() error {
+	return e.Err
+}
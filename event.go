@@ -0,0 +1,131 @@
+package tienlen_bot
+
+import "fmt"
+
+type EventKind uint8
+
+const (
+	// EventDeal is the cards dealt to PlayerIndex
+	EventDeal EventKind = iota
+	// EventMove is the combination dealt by PlayerIndex
+	EventMove
+	// EventPass is a pass of PlayerIndex
+	EventPass
+	// EventRoundReset is a new round led by PlayerIndex after everybody else passed
+	EventRoundReset
+	// EventChop is a chop received by PlayerIndex, described by Chop
+	EventChop
+	// EventEnd is the end of the game won by PlayerIndex
+	EventEnd
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventDeal:
+		return "deal"
+	case EventMove:
+		return "move"
+	case EventPass:
+		return "pass"
+	case EventRoundReset:
+		return "round reset"
+	case EventChop:
+		return "chop"
+	case EventEnd:
+		return "end"
+	default:
+		return "undefined"
+	}
+}
+
+// Event is one entry of the history of a game
+type Event struct {
+	Kind        EventKind
+	PlayerIndex int
+	// Cards is the hand dealt by EventDeal
+	Cards []*Card
+	// Combination is the combination dealt by EventMove or the bomb of EventChop
+	Combination Combination
+	// Chop describes the chop of EventChop
+	Chop *ChopEvent
+	// Superseded is true on an EventChop chopped again in the same chain: the later EventChop
+	// holds the whole amount, so the chops of the history are the EventChop not superseded
+	Superseded bool
+	// HandSizes is the number of cards of every seat after the event
+	HandSizes []int
+	// Bot is whether the seat dealt by EventDeal is played by a bot when its cards are dealt
+	Bot bool
+	// Deal is the state of the table the cards of EventDeal are dealt on
+	Deal *DealState
+}
+
+// DealState is the state of the table when the cards are dealt, the same for every EventDeal of a game.
+// It is the start of a new hand or the position a game was created at.
+type DealState struct {
+	CurrentPlayerIndex  int
+	PreviousPlayerIndex int
+	// Passed has one flag for every seat
+	Passed               []bool
+	LastDealtCombination Combination
+	IsFirstTurn          bool
+	PlayedCards          []*Card
+	FinishingOrder       []int
+	// NoInstantWins is true when instant wins are not checked on the deal,
+	// as for a game created from a position
+	NoInstantWins bool
+}
+
+func (e *Event) String() string {
+	switch e.Kind {
+	case EventDeal:
+		return fmt.Sprintf("%s %d %v", e.Kind, e.PlayerIndex, e.Cards)
+	case EventMove, EventChop:
+		return fmt.Sprintf("%s %d %s %v", e.Kind, e.PlayerIndex, e.Combination, e.HandSizes)
+	default:
+		return fmt.Sprintf("%s %d %v", e.Kind, e.PlayerIndex, e.HandSizes)
+	}
+}
+
+// Replay rebuilds the game described by history, which must start with the deal of every seat.
+// The table the cards are dealt on and the bot flags come from the deal events, only the
+// settings of config are used: rules, penalties, rewards, instant wins and seed.
+// Moves and passes are played again with their rules checked, the other events are
+// consequences of them. Giving a prefix of the history rebuilds an intermediate state.
+func Replay(config *GameConfiguration, history []Event) (Game, error) {
+	var game *LocalGame
+	for i, event := range history {
+		switch event.Kind {
+		case EventDeal:
+			if game == nil {
+				if event.Deal == nil {
+					return nil, fmt.Errorf("%w: event %d deals without the state of the table", ErrInvalidHistory, i)
+				}
+				game = newGameAt(config, event.Deal)
+			}
+			if game.CurrentNumberOfPlayers() != event.PlayerIndex {
+				return nil, fmt.Errorf("%w: event %d deals seat %d", ErrInvalidHistory, i, event.PlayerIndex)
+			}
+			cards := make([]*Card, len(event.Cards))
+			copy(cards, event.Cards)
+			player := NewPlayer()
+			player.SetBot(event.Bot)
+			player.SetCards(cards)
+			game.AddPlayer(player)
+		case EventMove, EventPass:
+			if game == nil || game.CurrentNumberOfPlayers() != game.GetMaxPlayerNumber() {
+				return nil, fmt.Errorf("%w: event %d is played before the deal", ErrInvalidHistory, i)
+			}
+			combination := event.Combination
+			if event.Kind == EventPass {
+				combination = NewPass()
+			}
+			if err := game.TryMoveAt(event.PlayerIndex, combination); err != nil {
+				return nil, fmt.Errorf("%w: event %d: %v", ErrInvalidHistory, i, err)
+			}
+		}
+	}
+	if game == nil {
+		return nil, fmt.Errorf("%w: no deal", ErrInvalidHistory)
+	}
+	return game, nil
+}
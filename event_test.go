@@ -0,0 +1,39 @@
+package tienlen_bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestEventsJSONRoundTripReplays(t *testing.T) {
+	for seed := int64(1); seed <= 50; seed++ {
+		config := NewDefaultGameConfig(4)
+		config.Seed = seed
+		game := createGame(config)
+		game.PlayRandomUntilEnd()
+
+		data, err := json.Marshal(game.GetEvents())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var events []Event
+		if err := json.Unmarshal(data, &events); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		again, err := json.Marshal(events)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, again) {
+			t.Fatalf("seed %d: events written again as\n%s\ninstead of\n%s", seed, again, data)
+		}
+		replayed, err := Replay(config, events)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if got, want := FormatPosition(replayed), FormatPosition(game); got != want {
+			t.Fatalf("seed %d: replayed %s, played %s", seed, got, want)
+		}
+	}
+}
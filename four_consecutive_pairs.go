@@ -49,26 +49,6 @@ func (f *FourConsecutivePairs) Cards() []*Card {
 	}
 }
 
-func (f *FourConsecutivePairs) Defeats(combination Combination) bool {
-	switch combination.Kind() {
-	case CombinationFourConsecutivePairs:
-		o := combination.(*FourConsecutivePairs)
-		if f.minRank == o.minRank {
-			return f.maxSuit > o.maxSuit
-		}
-		return f.minRank > o.minRank
-	case CombinationQuads:
-		return true
-	case CombinationThreeConsecutivePairs:
-		return true
-	case CombinationDubs:
-		return combination.(*Dubs).rank == Two
-	case CombinationSingle:
-		return combination.(*SingleCard).card.rank == Two
-	}
-	return false
-}
-
 func (f *FourConsecutivePairs) Copy() Combination {
 	return NewFourConsecutivePairs(f.dubs1.Copy().(*Dubs), f.dubs2.Copy().(*Dubs),
 		f.dubs3.Copy().(*Dubs), f.dubs4.Copy().(*Dubs))
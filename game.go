@@ -13,6 +13,18 @@ type GameConfiguration struct {
 	Rape                      bool
 	IsFirstTurn               bool
 	UseHeuristic              bool
+	// Seed makes the random playouts of the game reproducible, 0 means a seed taken from the clock
+	Seed                      int64
+	// Rules decides which combinations defeat others, nil means the Cambodian rules
+	Rules                     RuleSet
+	// InstantWins lists the patterns which win as soon as the cards are dealt, nil disables instant wins.
+	// They are off by default, AllInstantWinPatterns turns every pattern on.
+	// They are only checked on a fresh deal of 13 cards to every seat.
+	InstantWins               []InstantWinPattern
+	// Penalties is the points paid for chops and at the end of the hand, nil means NewDefaultPenaltyTable
+	Penalties                 *PenaltyTable
+	// PlayUntilRanked keeps playing after the first player empties the hand until every place is decided
+	PlayUntilRanked           bool
 }
 
 func NewDefaultGameConfig(maxPlayers int) *GameConfiguration {
@@ -25,16 +37,24 @@ func NewDefaultGameConfig(maxPlayers int) *GameConfiguration {
 		Rape:                      false,
 		IsFirstTurn:               true,
 		UseHeuristic:              true,
+		Seed:                      0,
+		Rules:                     NewCambodianRules(),
+		InstantWins:               nil,
+		Penalties:                 NewDefaultPenaltyTable(),
 	}
 }
 
 type Game interface {
 	Move(combination Combination)
+	TryMove(combination Combination) error
+	TryMoveAt(playerIndex int, combination Combination) error
 	GetCurrentPlayer() Player
 	GetCurrentPlayerIndex() int
 	GetPreviousPlayerIndex() int
 	GetWinnerIndex() int
 	GetMaxPlayerNumber() int
+	// Copy returns a copy of the game for searching, the moves played on it are not recorded:
+	// its events stop at the events of the game it was copied from and it has nothing to undo or redo
 	Copy() Game
 	AllAvailableCombinations() []Combination
 	IsEnd() bool
@@ -50,6 +70,34 @@ type Game interface {
 	PlayerPassed(index int) bool
 	GetLastDealtCombination() Combination
 	HasNoLastDealtCombination() bool
+	GetPlayedCards() []*Card
+	Determinize(observerIndex int) Game
+	DeterminizeFrom(inference *HandInference) Game
+	GetPlayHistory() []Turn
+	IsFirstTurn() bool
+	SetRandom(random *rand.Rand)
+	GetRandom() *rand.Rand
+	GetRules() RuleSet
+	// GetInstantWin returns the pattern the winner was dealt, InstantWinNone if nobody won at deal time
+	GetInstantWin() InstantWinPattern
+	// GetChopEvents returns every chop of the game in the order they were dealt
+	GetChopEvents() []ChopEvent
+	// GetFinishingOrder returns the seats which emptied their hand, first place first.
+	// Once a game played until ranked ends it holds every seat.
+	GetFinishingOrder() []int
+	// GetEvents returns the history of the game, it can be given to Replay.
+	// On a copy it is the history of the game it was copied from.
+	GetEvents() []Event
+	// Undo takes back the last move or pass
+	Undo() error
+	// Redo plays again the last move taken back, any other move clears the moves to redo
+	Redo() error
+}
+
+// Turn is one move of the game: the combination dealt by a player, or a pass
+type Turn struct {
+	PlayerIndex int
+	Combination Combination
 }
 
 type LocalGame struct {
@@ -65,9 +113,59 @@ type LocalGame struct {
 	isFirstTurn          bool
 	isEnd                bool
 	ply                  int
+	playedCards          []*Card
+	events               []Event
+	// recording là false trên các bản copy dùng để tìm kiếm, các nước đi trên đó không ghi sự kiện
+	// và không thể hoàn tác
+	recording            bool
+	random               *rand.Rand
+	rules                RuleSet
+	instantWin           InstantWinPattern
+	instantWinnerIndex   int
+	// noInstantWins là true nếu không xét tới trắng khi chia bài, với game tạo từ một position
+	noInstantWins        bool
+	penalties            *PenaltyTable
+	chopEvents           []ChopEvent
+	chopChain            int
+	finishingOrder       []int
+	undoStack            []undoRecord
+	redoStack            []Combination
+	// scores là cache điểm bài của các bản copy dùng để tìm kiếm, được chia sẻ bởi Copy
+	// và chỉ được dùng bởi một luồng, nil thì tính điểm trên player
+	scores               *handScoreCache
+}
+
+// undoRecord lưu trạng thái của game trước một nước đi để có thể hoàn tác,
+// các danh sách chỉ được append nên chỉ cần lưu độ dài, trừ chopEvents và events
+// vì chặt chồng thay sự kiện chặt cuối cùng và đánh dấu EventChop trước đó
+type undoRecord struct {
+	combination          Combination
+	currentPlayerIndex   int
+	previousPlayerIndex  int
+	passedPlayersCheck   []bool
+	lastDealtCombination Combination
+	isFirstTurn          bool
+	isEnd                bool
+	ply                  int
+	playedCards          int
+	events               []Event
+	chopEvents           []ChopEvent
+	chopChain            int
+	finishingOrder       int
 }
 
 func NewGame(config *GameConfiguration) Game {
+	rules := config.Rules
+	if isNil(rules) {
+		rules = NewCambodianRules()
+	}
+	penalties := config.Penalties
+	if penalties == nil {
+		penalties = NewDefaultPenaltyTable()
+	}
+	// copy để config giữ nguyên trạng thái ban đầu của game
+	passed := make([]bool, config.MaxPlayer)
+	copy(passed, config.Passed)
 	return &LocalGame{
 		players:              make([]Player, config.MaxPlayer),
 		reward:               nil,
@@ -76,31 +174,172 @@ func NewGame(config *GameConfiguration) Game {
 		currentPlayerIndex:   config.CurrentPlayerIndex,
 		previousPlayerIndex:  config.PreviousPlayerIndex,
 		lastDealtCombination: config.LastDealtCombination,
-		passedPlayersCheck:   config.Passed,
+		passedPlayersCheck:   passed,
 		config:               config,
 		isFirstTurn:          config.IsFirstTurn,
 		isEnd:                false,
 		ply:                  0,
+		recording:            true,
+		random:               newRandom(config.Seed),
+		rules:                rules,
+		instantWin:           InstantWinNone,
+		instantWinnerIndex:   -1,
+		penalties:            penalties,
 	}
 }
 
 func (l *LocalGame) Move(combination Combination) {
+	l.redoStack = nil
+	l.move(combination)
+}
+
+func (l *LocalGame) move(combination Combination) {
+	l.pushUndoRecord(combination)
 	l.ply++
 	if l.isFirstTurn {
 		l.isFirstTurn = false
 	}
+	playerIndex := l.currentPlayerIndex
 	if combination.Kind() == CombinationPass {
 		if l.currentPlayerIndex == l.previousPlayerIndex {
 			panic("current player can not pass (must move)")
 		}
 		l.passedPlayersCheck[l.currentPlayerIndex] = true
-		l.NextTurn()
-		return
+		l.addEvent(Event{Kind: EventPass, PlayerIndex: playerIndex})
+	} else {
+		chopped, chained := l.chop(combination)
+		l.lastDealtCombination = combination
+		l.playedCards = append(l.playedCards, combination.Cards()...)
+		l.GetCurrentPlayer().Remove(combination)
+		l.previousPlayerIndex = l.currentPlayerIndex
+		l.addEvent(Event{Kind: EventMove, PlayerIndex: playerIndex, Combination: combination})
+		if chained {
+			l.supersedeLastChop()
+		}
+		if chopped {
+			chop := l.chopEvents[len(l.chopEvents)-1]
+			l.addEvent(Event{Kind: EventChop, PlayerIndex: playerIndex, Combination: combination, Chop: &chop})
+		}
 	}
-	l.lastDealtCombination = combination
-	l.GetCurrentPlayer().Remove(combination)
-	l.previousPlayerIndex = l.currentPlayerIndex
 	l.NextTurn()
+	if l.isEnd {
+		l.addEvent(Event{Kind: EventEnd, PlayerIndex: l.GetWinnerIndex()})
+	} else if l.currentPlayerIndex == l.previousPlayerIndex {
+		l.addEvent(Event{Kind: EventRoundReset, PlayerIndex: l.currentPlayerIndex})
+	}
+}
+
+func (l *LocalGame) pushUndoRecord(combination Combination) {
+	if !l.recording {
+		return
+	}
+	passed := make([]bool, len(l.passedPlayersCheck))
+	copy(passed, l.passedPlayersCheck)
+	l.undoStack = append(l.undoStack, undoRecord{
+		combination:          combination,
+		currentPlayerIndex:   l.currentPlayerIndex,
+		previousPlayerIndex:  l.previousPlayerIndex,
+		passedPlayersCheck:   passed,
+		lastDealtCombination: l.lastDealtCombination,
+		isFirstTurn:          l.isFirstTurn,
+		isEnd:                l.isEnd,
+		ply:                  l.ply,
+		playedCards:          len(l.playedCards),
+		events:               l.events[:len(l.events):len(l.events)],
+		chopEvents:           l.chopEvents[:len(l.chopEvents):len(l.chopEvents)],
+		chopChain:            l.chopChain,
+		finishingOrder:       len(l.finishingOrder),
+	})
+}
+
+func (l *LocalGame) Undo() error {
+	if len(l.undoStack) == 0 {
+		return ErrNothingToUndo
+	}
+	r := l.undoStack[len(l.undoStack)-1]
+	// giới hạn capacity để append sau này không ghi đè lên các bản copy dùng chung mảng
+	l.undoStack = l.undoStack[:len(l.undoStack)-1 : len(l.undoStack)-1]
+	if r.combination.Kind() != CombinationPass {
+		l.players[r.currentPlayerIndex].UndoRemove()
+	}
+	l.currentPlayerIndex = r.currentPlayerIndex
+	l.previousPlayerIndex = r.previousPlayerIndex
+	copy(l.passedPlayersCheck, r.passedPlayersCheck)
+	l.lastDealtCombination = r.lastDealtCombination
+	l.isFirstTurn = r.isFirstTurn
+	l.isEnd = r.isEnd
+	l.ply = r.ply
+	l.playedCards = l.playedCards[:r.playedCards:r.playedCards]
+	l.events = r.events
+	l.chopEvents = r.chopEvents
+	l.chopChain = r.chopChain
+	l.finishingOrder = l.finishingOrder[:r.finishingOrder:r.finishingOrder]
+	l.reward = nil
+	l.redoStack = append(l.redoStack, r.combination)
+	return nil
+}
+
+func (l *LocalGame) Redo() error {
+	if len(l.redoStack) == 0 {
+		return ErrNothingToRedo
+	}
+	combination := l.redoStack[len(l.redoStack)-1]
+	l.redoStack = l.redoStack[:len(l.redoStack)-1 : len(l.redoStack)-1]
+	l.move(combination)
+	return nil
+}
+
+// TryMove plays combination for the current player, or returns a *MoveError
+// and leaves the game unchanged when the move is not allowed
+func (l *LocalGame) TryMove(combination Combination) error {
+	return l.TryMoveAt(l.currentPlayerIndex, combination)
+}
+
+// TryMoveAt plays combination for the player at playerIndex, or returns a *MoveError
+// and leaves the game unchanged when the move is not allowed
+func (l *LocalGame) TryMoveAt(playerIndex int, combination Combination) error {
+	own, err := l.checkMove(playerIndex, combination)
+	if err != nil {
+		return newMoveError(playerIndex, combination, err)
+	}
+	l.Move(own)
+	return nil
+}
+
+// checkMove returns the combination of the player's hand equal to combination
+// or the reason it can not be played
+func (l *LocalGame) checkMove(playerIndex int, combination Combination) (Combination, error) {
+	if l.isEnd {
+		return nil, ErrGameEnded
+	}
+	if playerIndex != l.currentPlayerIndex {
+		return nil, ErrNotYourTurn
+	}
+	if isNil(combination) {
+		return nil, ErrInvalidCombination
+	}
+	if combination.Kind() == CombinationPass {
+		if l.currentPlayerIndex == l.previousPlayerIndex {
+			return nil, ErrLeaderCannotPass
+		}
+		return combination, nil
+	}
+	player := l.GetCurrentPlayer()
+	own := player.GetCombination(combination)
+	if isNil(own) {
+		return nil, ErrCombinationNotInHand
+	}
+	if l.currentPlayerIndex == l.previousPlayerIndex {
+		card := player.GetSmallestCard()
+		if l.isFirstTurn && card.rank == Three && card.suit == Spade && !containsCard(own.Cards(), card) {
+			return nil, ErrFirstPlayMustContainThreeOfSpades
+		}
+		return own, nil
+	}
+	if notNil(l.lastDealtCombination) && !l.rules.Defeats(own, l.lastDealtCombination) {
+		return nil, ErrDoesNotDefeat
+	}
+	return own, nil
 }
 
 func (l *LocalGame) GetCurrentPlayer() Player {
@@ -116,8 +355,14 @@ func (l *LocalGame) GetPreviousPlayerIndex() int {
 }
 
 func (l *LocalGame) GetWinnerIndex() int {
+	if l.instantWin != InstantWinNone {
+		return l.instantWinnerIndex
+	}
+	if len(l.finishingOrder) > 0 {
+		return l.finishingOrder[0]
+	}
 	for i := 0; i < l.maxNumberOfPlayers; i++ {
-		if len(l.players[i].AllAvailableCombinations()) == 0 {
+		if l.players[i].GetCardsLength() == 0 {
 			return i
 		}
 	}
@@ -142,6 +387,22 @@ func (l *LocalGame) Copy() Game {
 		isFirstTurn:          l.isFirstTurn,
 		isEnd:                l.isEnd,
 		ply:                  l.ply,
+		// giới hạn capacity để append trên bản copy không ghi đè lên game gốc
+		playedCards:          l.playedCards[:len(l.playedCards):len(l.playedCards)],
+		events:               l.events[:len(l.events):len(l.events)],
+		recording:            false,
+		random:               l.random,
+		rules:                l.rules,
+		instantWin:           l.instantWin,
+		instantWinnerIndex:   l.instantWinnerIndex,
+		noInstantWins:        l.noInstantWins,
+		penalties:            l.penalties,
+		chopEvents:           l.chopEvents[:len(l.chopEvents):len(l.chopEvents)],
+		chopChain:            l.chopChain,
+		finishingOrder:       l.finishingOrder[:len(l.finishingOrder):len(l.finishingOrder)],
+		undoStack:            nil,
+		redoStack:            nil,
+		scores:               l.scores,
 	}
 	for i := 0; i < l.maxNumberOfPlayers; i++ {
 		game.players[i] = l.players[i].Copy()
@@ -155,24 +416,41 @@ func (l *LocalGame) Copy() Game {
 
 func (l *LocalGame) AllAvailableCombinations() []Combination {
 	player := l.GetCurrentPlayer()
+	// sinh thẳng từ các lá trên tay, không dùng danh sách bộ bài của player.
+	// Người đi đầu vòng đánh bộ nào cũng được
+	target := l.lastDealtCombination
 	if l.previousPlayerIndex == l.currentPlayerIndex {
-		list := player.AllAvailableCombinations()
-		if l.isFirstTurn {
-			card := player.GetSmallestCard()
-			if card.rank == Three && card.suit == Spade {
-				availableCards := []Combination{}
-				for i := 0; i < len(list); i++ {
-					if containsCard(list[i].Cards(), card) {
-						availableCards = append(availableCards, list[i])
-					}
+		target = nil
+	}
+	list := NewMoveGenerator(player.GetHand(), target, l.rules).All()
+	if l.previousPlayerIndex == l.currentPlayerIndex && l.isFirstTurn {
+		card := player.GetSmallestCard()
+		if card.rank == Three && card.suit == Spade {
+			availableCards := []Combination{}
+			for i := 0; i < len(list); i++ {
+				if containsCard(list[i].Cards(), card) {
+					availableCards = append(availableCards, list[i])
 				}
-				return availableCards
 			}
+			return availableCards
 		}
-		return list
-	} else {
-		return player.AllAvailableCombinationsDefeat(l.lastDealtCombination)
 	}
+	return list
+}
+
+// finishingCombination trả về bộ trong list dùng hết length lá còn lại trên tay, nil nếu không có.
+// Mỗi tập lá chỉ tạo thành một bộ nên có nhiều nhất một bộ như vậy
+func finishingCombination(list []Combination, length int) Combination {
+	for i := len(list) - 1; i >= 0; i-- {
+		if len(list[i].Cards()) == length {
+			return list[i]
+		}
+	}
+	return nil
+}
+
+func (l *LocalGame) GetRules() RuleSet {
+	return l.rules
 }
 
 func (l *LocalGame) IsEnd() bool {
@@ -185,19 +463,29 @@ func (l *LocalGame) PlayRandomUntilEnd() {
 			break
 		}
 		list := l.AllAvailableCombinations()
-		if len(list) > 0 &&
-			len(list[len(list)-1].Cards()) == l.GetCurrentPlayer().GetCardsLength() {
-			l.Move(list[len(list)-1])
-			break
+		if combination := finishingCombination(list, l.GetCurrentPlayer().GetCardsLength()); notNil(combination) {
+			l.Move(combination)
+			continue
 		}
 		if len(list) == 0 || l.currentPlayerIndex != l.previousPlayerIndex {
 			list = append(list, NewPass())
 		}
-		combination := list[rand.Intn(len(list))]
+		combination := list[l.random.Intn(len(list))]
 		l.Move(combination)
 	}
 	l.reward = NewReward(l.maxNumberOfPlayers)
-	if l.config.Rape {
+	if l.instantWin != InstantWinNone {
+		for i := 0; i < l.maxNumberOfPlayers; i++ {
+			l.reward.SetScore(i, ifThen(i == l.instantWinnerIndex, 1+FactorInstantWin, -FactorInstantWin).(float64))
+		}
+		return
+	}
+	if l.config.PlayUntilRanked {
+		// người về nhất được 1, người về bét được 0
+		for place, index := range l.finishingOrder {
+			l.reward.SetScore(index, float64(l.maxNumberOfPlayers-1-place)/float64(l.maxNumberOfPlayers-1))
+		}
+	} else if l.config.Rape {
 		winner := l.GetWinnerIndex()
 		if l.players[winner].IsBot() {
 			for i := 0; i < l.maxNumberOfPlayers; i++ {
@@ -209,7 +497,7 @@ func (l *LocalGame) PlayRandomUntilEnd() {
 			}
 		} else {
 			for i := 0; i < l.maxNumberOfPlayers; i++ {
-				l.reward.SetScore(i, ifThen(len(l.players[i].AllAvailableCombinations()) < 0, float64(1), float64(0)).(float64))
+				l.reward.SetScore(i, ifThen(l.players[i].GetCardsLength() < 0, float64(1), float64(0)).(float64))
 			}
 		}
 	} else {
@@ -217,22 +505,33 @@ func (l *LocalGame) PlayRandomUntilEnd() {
 			winner := l.GetWinnerIndex()
 			total := - float64(l.ply) * FactorPly
 			for i := 0; i < l.maxNumberOfPlayers; i++ {
-				total += l.players[i].GetScore()
+				total += l.playerScore(i)
 			}
 
 			for i := 0; i < l.maxNumberOfPlayers; i++ {
 				if i == winner {
 					l.reward.SetScore(i, 1+total)
 				} else {
-					l.reward.SetScore(i, - l.GetPlayerAt(i).GetScore())
+					l.reward.SetScore(i, - l.playerScore(i))
 				}
 			}
 		} else {
 			for i := 0; i < l.maxNumberOfPlayers; i++ {
-				l.reward.SetScore(i, ifThen(len(l.GetPlayerAt(i).AllAvailableCombinations()) <= 0, float64(1), float64(0)).(float64))
+				l.reward.SetScore(i, ifThen(l.GetPlayerAt(i).GetCardsLength() <= 0, float64(1), float64(0)).(float64))
 			}
 		}
 	}
+	for i, score := range chopScores(l.chopEvents, l.maxNumberOfPlayers) {
+		l.reward.SetScore(i, l.reward.GetScoreOfPlayer(i)+float64(score)*FactorChopPoint)
+	}
+}
+
+// playerScore là điểm bài còn lại của ghế i, lấy từ cache của game nếu có
+func (l *LocalGame) playerScore(i int) float64 {
+	if l.scores == nil {
+		return l.players[i].GetScore()
+	}
+	return l.scores.score(l.players[i].GetHand())
 }
 
 func (l *LocalGame) GetReward() Reward {
@@ -246,12 +545,164 @@ func (l *LocalGame) AddPlayer(player Player) {
 			player.Validate()
 			l.players[i] = player
 			l.size++
+			l.addEvent(Event{
+				Kind:        EventDeal,
+				PlayerIndex: i,
+				Cards:       player.GetOriginalCards(),
+				Bot:         player.IsBot(),
+				Deal:        l.dealState(),
+			})
 			break
 		}
 	}
 	if l.size == l.maxNumberOfPlayers {
 		l.Validate()
+		l.detectInstantWin()
+		l.detectEmptyHand()
+	}
+}
+
+// detectEmptyHand ghi nhận những người đã hết bài của game tạo từ một position và kết thúc game,
+// người thắng là người về đầu tiên. Khi chơi đến khi xếp hạng hết, game tiếp tục với những người còn bài
+func (l *LocalGame) detectEmptyHand() {
+	if l.isEnd {
+		return
+	}
+	for i := 0; i < l.maxNumberOfPlayers; i++ {
+		if l.players[i].GetCardsLength() == 0 {
+			if !l.finished(i) {
+				l.finishingOrder = append(l.finishingOrder, i)
+			}
+			l.isEnd = !l.config.PlayUntilRanked
+		}
+	}
+	// khi chơi đến khi xếp hạng hết, người cuối cùng còn bài về bét và game kết thúc
+	if l.config.PlayUntilRanked && len(l.finishingOrder) >= l.maxNumberOfPlayers-1 {
+		for i := 0; i < l.maxNumberOfPlayers; i++ {
+			if !l.finished(i) {
+				l.finishingOrder = append(l.finishingOrder, i)
+			}
+		}
+		l.isEnd = true
+	}
+	if l.isEnd {
+		l.addEvent(Event{Kind: EventEnd, PlayerIndex: l.GetWinnerIndex()})
+	}
+}
+
+// dealState trả về trạng thái của bàn lúc chia bài, các slice được copy
+func (l *LocalGame) dealState() *DealState {
+	state := &DealState{
+		CurrentPlayerIndex:   l.currentPlayerIndex,
+		PreviousPlayerIndex:  l.previousPlayerIndex,
+		Passed:               make([]bool, len(l.passedPlayersCheck)),
+		LastDealtCombination: l.lastDealtCombination,
+		IsFirstTurn:          l.isFirstTurn,
+		PlayedCards:          append([]*Card{}, l.playedCards...),
+		FinishingOrder:       append([]int{}, l.finishingOrder...),
+		NoInstantWins:        l.noInstantWins,
 	}
+	copy(state.Passed, l.passedPlayersCheck)
+	return state
+}
+
+// newGameAt tạo game chưa có người chơi bắt đầu từ trạng thái state, các thiết lập khác lấy từ config
+func newGameAt(config *GameConfiguration, state *DealState) *LocalGame {
+	c := *config
+	c.MaxPlayer = len(state.Passed)
+	c.CurrentPlayerIndex = state.CurrentPlayerIndex
+	c.PreviousPlayerIndex = state.PreviousPlayerIndex
+	c.Passed = make([]bool, len(state.Passed))
+	copy(c.Passed, state.Passed)
+	c.LastDealtCombination = state.LastDealtCombination
+	c.IsFirstTurn = state.IsFirstTurn
+	game := NewGame(&c).(*LocalGame)
+	game.playedCards = append([]*Card{}, state.PlayedCards...)
+	game.finishingOrder = append([]int{}, state.FinishingOrder...)
+	game.noInstantWins = state.NoInstantWins
+	return game
+}
+
+// detectInstantWin kết thúc game ngay khi chia bài nếu có người tới trắng,
+// xét lần lượt từ người đi đầu. Chỉ xét khi vừa chia đủ 13 lá cho mọi người và chưa có lá nào được đánh
+func (l *LocalGame) detectInstantWin() {
+	if l.noInstantWins || len(l.config.InstantWins) == 0 || len(l.playedCards) > 0 {
+		return
+	}
+	for _, player := range l.players {
+		if player.GetCardsLength() != 13 {
+			return
+		}
+	}
+	for i := 0; i < l.maxNumberOfPlayers; i++ {
+		index := (l.currentPlayerIndex + i) % l.maxNumberOfPlayers
+		pattern := DetectInstantWin(l.players[index].GetRemainingCards(), l.config.InstantWins)
+		if pattern != InstantWinNone {
+			l.instantWin = pattern
+			l.instantWinnerIndex = index
+			l.isEnd = true
+			l.addEvent(Event{Kind: EventEnd, PlayerIndex: index})
+			return
+		}
+	}
+}
+
+// chop ghi lại sự kiện chặt nếu combination chặt 2 hoặc chặt hàng của người đánh trước trong cùng vòng,
+// số điểm cộng dồn theo chuỗi chặt chồng và reset khi có nước đi không phải chặt.
+// Khi chặt chồng (chained = true), sự kiện chặt trước được thay bằng sự kiện mới để chỉ người bị chặt cuối cùng trả cả chuỗi
+func (l *LocalGame) chop(combination Combination) (chopped bool, chained bool) {
+	if l.currentPlayerIndex == l.previousPlayerIndex || isNil(l.lastDealtCombination) || !isBomb(combination) {
+		l.chopChain = 0
+		return false, false
+	}
+	value := l.penalties.ChopValue(l.lastDealtCombination)
+	if value == 0 {
+		l.chopChain = 0
+		return false, false
+	}
+	events := l.chopEvents
+	if !isBomb(l.lastDealtCombination) {
+		l.chopChain = 0
+	} else if l.chopChain > 0 {
+		chained = true
+		// bộ bị chặt chính là bộ chặt của sự kiện cuối, người chặt trước không còn được điểm;
+		// giới hạn capacity để không ghi đè lên bản lưu của undo và các bản copy
+		events = events[: len(events)-1 : len(events)-1]
+	}
+	l.chopChain += value
+	l.chopEvents = append(events, ChopEvent{
+		Payer:       l.previousPlayerIndex,
+		Receiver:    l.currentPlayerIndex,
+		Amount:      l.chopChain,
+		Combination: combination,
+		Target:      l.lastDealtCombination,
+	})
+	return true, chained
+}
+
+// supersedeLastChop đánh dấu EventChop cuối cùng là đã bị thay bởi lần chặt chồng, trên một bản copy của events
+// để không sửa lịch sử mà undo và các bản copy của game đang dùng
+func (l *LocalGame) supersedeLastChop() {
+	if !l.recording {
+		return
+	}
+	for i := len(l.events) - 1; i >= 0; i-- {
+		if l.events[i].Kind == EventChop {
+			events := make([]Event, len(l.events), len(l.events)+4)
+			copy(events, l.events)
+			events[i].Superseded = true
+			l.events = events
+			return
+		}
+	}
+}
+
+func (l *LocalGame) GetChopEvents() []ChopEvent {
+	return l.chopEvents
+}
+
+func (l *LocalGame) GetInstantWin() InstantWinPattern {
+	return l.instantWin
 }
 
 func (l *LocalGame) CurrentNumberOfPlayers() int {
@@ -267,7 +718,14 @@ func (l *LocalGame) Validate() {
 }
 
 func (l *LocalGame) NextTurn() {
-	l.isEnd = len(l.GetCurrentPlayer().AllAvailableCombinations()) == 0
+	if l.config.PlayUntilRanked {
+		l.nextRankedTurn()
+		return
+	}
+	l.isEnd = l.GetCurrentPlayer().GetCardsLength() == 0
+	if l.isEnd {
+		l.finishingOrder = append(l.finishingOrder, l.currentPlayerIndex)
+	}
 	l.increaseIndex()
 	for l.passedPlayersCheck[l.currentPlayerIndex] {
 		l.increaseIndex()
@@ -279,6 +737,52 @@ func (l *LocalGame) NextTurn() {
 	}
 }
 
+// nextRankedTurn chuyển lượt khi chơi đến khi xếp hạng hết: người đã về bị bỏ qua,
+// nếu bộ cuối cùng của người vừa về không bị chặn thì người kế tiếp còn bài được đi đầu
+func (l *LocalGame) nextRankedTurn() {
+	if l.GetCurrentPlayer().GetCardsLength() == 0 && !l.finished(l.currentPlayerIndex) {
+		l.finishingOrder = append(l.finishingOrder, l.currentPlayerIndex)
+		if len(l.finishingOrder) == l.maxNumberOfPlayers-1 {
+			for i := 0; i < l.maxNumberOfPlayers; i++ {
+				if !l.finished(i) {
+					l.finishingOrder = append(l.finishingOrder, i)
+				}
+			}
+			l.isEnd = true
+			return
+		}
+	}
+	for {
+		l.increaseIndex()
+		if l.currentPlayerIndex == l.previousPlayerIndex {
+			for l.finished(l.currentPlayerIndex) {
+				l.increaseIndex()
+			}
+			l.previousPlayerIndex = l.currentPlayerIndex
+			for i := range l.passedPlayersCheck {
+				l.passedPlayersCheck[i] = false
+			}
+			return
+		}
+		if !l.passedPlayersCheck[l.currentPlayerIndex] && !l.finished(l.currentPlayerIndex) {
+			return
+		}
+	}
+}
+
+func (l *LocalGame) finished(index int) bool {
+	for _, i := range l.finishingOrder {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *LocalGame) GetFinishingOrder() []int {
+	return l.finishingOrder
+}
+
 func (l *LocalGame) GetConfig() *GameConfiguration {
 	return l.config
 }
@@ -303,6 +807,107 @@ func (l *LocalGame) HasNoLastDealtCombination() bool {
 	return isNil(l.lastDealtCombination)
 }
 
+func (l *LocalGame) GetPlayedCards() []*Card {
+	return l.playedCards
+}
+
+// GetPlayHistory returns the moves and passes of the events
+func (l *LocalGame) GetPlayHistory() []Turn {
+	history := []Turn{}
+	for _, event := range l.events {
+		switch event.Kind {
+		case EventMove:
+			history = append(history, Turn{PlayerIndex: event.PlayerIndex, Combination: event.Combination})
+		case EventPass:
+			history = append(history, Turn{PlayerIndex: event.PlayerIndex, Combination: NewPass()})
+		}
+	}
+	return history
+}
+
+func (l *LocalGame) GetEvents() []Event {
+	return l.events
+}
+
+// addEvent ghi lại sự kiện cùng số bài còn lại của mọi người chơi
+func (l *LocalGame) addEvent(event Event) {
+	if !l.recording {
+		return
+	}
+	event.HandSizes = make([]int, l.maxNumberOfPlayers)
+	for i, player := range l.players {
+		if notNil(player) {
+			event.HandSizes[i] = player.GetCardsLength()
+		}
+	}
+	l.events = append(l.events, event)
+}
+
+func (l *LocalGame) IsFirstTurn() bool {
+	return l.isFirstTurn
+}
+
+// SetRandom sets the source of randomness used by the game and by the mcts nodes
+// searching on it. A rand.Rand is not safe for concurrent use, so every goroutine
+// working on a copy of the game must set its own.
+func (l *LocalGame) SetRandom(random *rand.Rand) {
+	l.random = random
+}
+
+func (l *LocalGame) GetRandom() *rand.Rand {
+	return l.random
+}
+
+// Determinize returns a copy of the game in which every hand except the observer's
+// is redealt at random from the cards the observer has not seen yet.
+// Hand sizes, passes and the last dealt combination are kept as they are.
+func (l *LocalGame) Determinize(observerIndex int) Game {
+	unseen := l.unseenCards(observerIndex)
+	l.random.Shuffle(len(unseen), func(i, j int) {
+		unseen[i], unseen[j] = unseen[j], unseen[i]
+	})
+	hands := make([][]*Card, l.maxNumberOfPlayers)
+	for i := 0; i < l.maxNumberOfPlayers; i++ {
+		if i == observerIndex {
+			continue
+		}
+		length := l.players[i].GetCardsLength()
+		hands[i] = make([]*Card, length)
+		copy(hands[i], unseen[:length])
+		unseen = unseen[length:]
+	}
+	return l.determinize(observerIndex, hands)
+}
+
+// DeterminizeFrom works like Determinize for the observer of inference,
+// but the hands are drawn from the estimates of inference instead of uniformly
+func (l *LocalGame) DeterminizeFrom(inference *HandInference) Game {
+	return l.determinize(inference.observer, inference.Sample(l.random))
+}
+
+// determinize trả về bản copy của game với bài của các ghế khác observerIndex là hands
+func (l *LocalGame) determinize(observerIndex int, hands [][]*Card) Game {
+	game := l.Copy().(*LocalGame)
+	for i := 0; i < l.maxNumberOfPlayers; i++ {
+		if i == observerIndex {
+			continue
+		}
+		cards := hands[i]
+		player := NewPlayer()
+		player.SetBot(l.players[i].IsBot())
+		player.SetIndex(i)
+		player.SetCards(cards)
+		player.Validate()
+		game.players[i] = player
+	}
+	return game
+}
+
+// unseenCards returns all cards that are neither in the observer's hand nor already played
+func (l *LocalGame) unseenCards(observerIndex int) []*Card {
+	return NewCardTracker(l.players[observerIndex].GetRemainingCards(), l.playedCards, l.rules).Unseen().Cards()
+}
+
 func (l *LocalGame) increaseIndex() {
 	if l.currentPlayerIndex == l.maxNumberOfPlayers-1 {
 		l.currentPlayerIndex = 0
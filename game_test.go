@@ -0,0 +1,193 @@
+package tienlen_bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// newSeededGame chia bài từ seed, ranked là chơi tới khi xếp hạng xong
+func newSeededGame(seed int64, players int, ranked bool) Game {
+	config := NewDefaultGameConfig(players)
+	config.Seed = seed
+	config.PlayUntilRanked = ranked
+	config.InstantWins = AllInstantWinPatterns()
+	game := NewGame(config)
+	deck := NewDeckWithSeed(seed)
+	for i := 0; i < players; i++ {
+		player := NewPlayer()
+		player.SetBot(i%2 == 1)
+		player.SetCards(deck.randomCards(13))
+		game.AddPlayer(player)
+	}
+	return game
+}
+
+// playRandomGame chơi ngẫu nhiên tới hết game và trả về trạng thái của game trước và sau mỗi nước đi
+func playRandomGame(t *testing.T, game Game, random *rand.Rand) []string {
+	states := []string{describeGame(game)}
+	for !game.IsEnd() {
+		moves := game.AllAvailableCombinations()
+		if game.GetCurrentPlayerIndex() != game.GetPreviousPlayerIndex() {
+			moves = append(moves, NewPass())
+		}
+		if err := game.TryMove(moves[random.Intn(len(moves))]); err != nil {
+			t.Fatal(err)
+		}
+		states = append(states, describeGame(game))
+	}
+	return states
+}
+
+// describeGame viết position, tới trắng và các lần chặt của game
+func describeGame(game Game) string {
+	return fmt.Sprintf("%s %s %v", FormatPosition(game), game.GetInstantWin(), game.GetChopEvents())
+}
+
+// eachRandomGame gọi f với các game 2, 3 và 4 người đã chơi ngẫu nhiên tới hết, có và không xếp hạng
+func eachRandomGame(t *testing.T, f func(t *testing.T, game Game, states []string)) {
+	for seed := int64(1); seed <= 30; seed++ {
+		for players := 2; players <= 4; players++ {
+			ranked := seed%2 == 0
+			t.Run(fmt.Sprintf("seed %d %d players ranked %v", seed, players, ranked), func(t *testing.T) {
+				game := newSeededGame(seed, players, ranked)
+				states := playRandomGame(t, game, rand.New(rand.NewSource(seed)))
+				f(t, game, states)
+			})
+		}
+	}
+}
+
+func TestReplayRebuildsTheGame(t *testing.T) {
+	eachRandomGame(t, func(t *testing.T, game Game, states []string) {
+		replayed, err := Replay(game.GetConfig(), game.GetEvents())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := describeGame(replayed), states[len(states)-1]; got != want {
+			t.Fatalf("replayed %s, played %s", got, want)
+		}
+		got, _ := json.Marshal(replayed.GetEvents())
+		want, _ := json.Marshal(game.GetEvents())
+		if string(got) != string(want) {
+			t.Fatalf("replayed events %s, played %s", got, want)
+		}
+	})
+}
+
+func TestReplayRejectsHistoryWithoutDeal(t *testing.T) {
+	game := newSeededGame(1, 4, false)
+	game.PlayRandomUntilEnd()
+	var moves []Event
+	for _, event := range game.GetEvents() {
+		if event.Kind != EventDeal {
+			moves = append(moves, event)
+		}
+	}
+	if _, err := Replay(game.GetConfig(), moves); !errors.Is(err, ErrInvalidHistory) {
+		t.Fatalf("error %v, want %v", err, ErrInvalidHistory)
+	}
+}
+
+func TestUndoAndRedoEveryMove(t *testing.T) {
+	eachRandomGame(t, func(t *testing.T, game Game, states []string) {
+		events := game.GetEvents()
+		for i := len(states) - 2; i >= 0; i-- {
+			if err := game.Undo(); err != nil {
+				t.Fatal(err)
+			}
+			if got := describeGame(game); got != states[i] {
+				t.Fatalf("undo to move %d: %s, want %s", i, got, states[i])
+			}
+		}
+		if err := game.Undo(); !errors.Is(err, ErrNothingToUndo) {
+			t.Fatalf("undo before the first move: %v", err)
+		}
+		for i := 1; i < len(states); i++ {
+			if err := game.Redo(); err != nil {
+				t.Fatal(err)
+			}
+			if got := describeGame(game); got != states[i] {
+				t.Fatalf("redo to move %d: %s, want %s", i, got, states[i])
+			}
+		}
+		if err := game.Redo(); !errors.Is(err, ErrNothingToRedo) {
+			t.Fatalf("redo after the last move: %v", err)
+		}
+		if !reflect.DeepEqual(game.GetEvents(), events) {
+			t.Fatalf("events after redo %v, want %v", game.GetEvents(), events)
+		}
+	})
+}
+
+func TestSameSeedDealsAndPlaysTheSameGame(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		first, second := newSeededGame(seed, 4, true), newSeededGame(seed, 4, true)
+		if got, want := FormatPosition(second), FormatPosition(first); got != want {
+			t.Fatalf("seed %d dealt %s then %s", seed, want, got)
+		}
+		first.PlayRandomUntilEnd()
+		second.PlayRandomUntilEnd()
+		if got, want := describeGame(second), describeGame(first); got != want {
+			t.Fatalf("seed %d played %s then %s", seed, want, got)
+		}
+	}
+}
+
+func TestChainedChopReplacesTheLastChop(t *testing.T) {
+	position, err := ParsePosition("2♠,3♣/4♠,4♣,4♦,4♥,5♠/6♠,6♣,6♦,6♥,7♠ 0 0 000 - 0 - -")
+	if err != nil {
+		t.Fatal(err)
+	}
+	game := NewGameFromPosition(NewDefaultGameConfig(3), position)
+	moves := []struct {
+		cards string
+		kind  CombinationKind
+	}{
+		{"2♠", CombinationSingle},
+		{"4♠,4♣,4♦,4♥", CombinationQuads},
+		{"6♠,6♣,6♦,6♥", CombinationQuads},
+	}
+	for _, move := range moves {
+		cards, err := TryParseCards(move.cards)
+		if err != nil {
+			t.Fatal(err)
+		}
+		combination, err := ParseCombination(cards, move.kind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := game.TryMove(combination); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(payer, amount int) {
+		t.Helper()
+		chops := game.GetChopEvents()
+		if len(chops) != 1 || chops[0].Payer != payer || chops[0].Amount != amount {
+			t.Fatalf("chops %v, want seat %d paying %d", chops, payer, amount)
+		}
+		// các EventChop không bị thay thế là các lần chặt của game
+		live := []ChopEvent{}
+		for _, event := range game.GetEvents() {
+			if event.Kind == EventChop && !event.Superseded {
+				live = append(live, *event.Chop)
+			}
+		}
+		if !reflect.DeepEqual(live, chops) {
+			t.Fatalf("chops of the events %v, want %v", live, chops)
+		}
+	}
+	check(1, 20)
+	if err := game.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	check(0, 5)
+	if err := game.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	check(1, 20)
+}
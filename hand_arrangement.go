@@ -0,0 +1,249 @@
+package tienlen_bot
+
+import (
+	"sort"
+)
+
+// handScoreCacheSize là số tập lá tối đa được lưu điểm, vượt quá thì xóa hết
+const handScoreCacheSize = 1 << 16
+
+// handScoreCache lưu tổng điểm của cách chia bài theo từng tập lá. Các playout từ cùng một thế bài
+// kết thúc với các tập con của cùng những bộ bài, nên mỗi luồng search giữ một cache trên bản copy
+// game của nó và không cần khóa
+type handScoreCache struct {
+	scores map[CardSet]float64
+}
+
+func newHandScoreCache() *handScoreCache {
+	return &handScoreCache{scores: make(map[CardSet]float64)}
+}
+
+// HandScore is the breakdown of how much a hand still costs the player holding it,
+// every field is already weighted by its factor in reward.go.
+type HandScore struct {
+	Cards     float64
+	Plays     float64
+	Leftovers float64
+	Twos      float64
+	Bombs     float64
+}
+
+// Total returns the sum of the breakdown, the higher the worse the hand
+func (s HandScore) Total() float64 {
+	return s.Cards + s.Plays + s.Leftovers + s.Twos + s.Bombs
+}
+
+// HandArrangement is a split of a hand into disjoint combinations.
+// Plays is the number of combinations, Leftovers the number of singles and dubs below 2
+// which any higher single or dubs beats, so they can only be got rid of on a lead.
+type HandArrangement struct {
+	Combinations []Combination
+	Plays        int
+	Leftovers    int
+	Score        HandScore
+}
+
+// ArrangeHand returns the arrangement of cards with the fewest plays,
+// and among those the one with the fewest leftovers
+func ArrangeHand(cards []*Card) *HandArrangement {
+	return arrangeHand(NewCardSet(cards...))
+}
+
+func arrangeHand(hand CardSet) *HandArrangement {
+	a := &arranger{memo: make(map[CardSet]arrangement)}
+	// lá không ghép được với lá nào luôn là một bộ riêng, không cần tìm kiếm
+	isolated := isolatedCards(hand)
+	best := a.solve(hand.Difference(isolated))
+	arrangement := &HandArrangement{
+		Combinations: []Combination{},
+		Plays:        best.plays + isolated.Len(),
+		Leftovers:    best.leftovers + isolated.Difference(RankMask(Two)).Len(),
+	}
+	for _, card := range isolated.Cards() {
+		arrangement.Combinations = append(arrangement.Combinations, NewSingleCard(card))
+	}
+	for rest := hand.Difference(isolated); !rest.IsEmpty(); rest = rest.Difference(a.memo[rest].set) {
+		step := a.memo[rest]
+		combination, err := ParseCombination(step.set.Cards(), step.kind)
+		if err != nil {
+			panic(err.Error())
+		}
+		arrangement.Combinations = append(arrangement.Combinations, combination)
+	}
+	sort.Slice(arrangement.Combinations, func(i, j int) bool {
+		return compareCard(arrangement.Combinations[i].Cards()[0], arrangement.Combinations[j].Cards()[0]) < 0
+	})
+	arrangement.Score = scoreArrangement(hand, arrangement)
+	return arrangement
+}
+
+// score trả về arrangeHand(hand).Score.Total(), lấy từ cache nếu đã tính
+func (c *handScoreCache) score(hand CardSet) float64 {
+	if score, ok := c.scores[hand]; ok {
+		return score
+	}
+	score := arrangeHand(hand).Score.Total()
+	if len(c.scores) >= handScoreCacheSize {
+		c.scores = make(map[CardSet]float64)
+	}
+	c.scores[hand] = score
+	return score
+}
+
+// arrangement là bộ đầu tiên trong cách chia tốt nhất của một tập lá,
+// phần còn lại được lấy từ memo của tập lá trừ đi set
+type arrangement struct {
+	kind      CombinationKind
+	set       CardSet
+	plays     int
+	leftovers int
+}
+
+func (a arrangement) better(o arrangement) bool {
+	if a.plays != o.plays {
+		return a.plays < o.plays
+	}
+	return a.leftovers < o.leftovers
+}
+
+type arranger struct {
+	memo map[CardSet]arrangement
+}
+
+// solve tìm cách chia tốt nhất của hand, lá nhỏ nhất luôn nằm trong đúng một bộ
+// nên chỉ cần thử các bộ chứa lá đó
+func (a *arranger) solve(hand CardSet) arrangement {
+	if hand.IsEmpty() {
+		return arrangement{}
+	}
+	if best, ok := a.memo[hand]; ok {
+		return best
+	}
+	best := arrangement{plays: -1}
+	eachCombinationWithSmallest(hand, func(kind CombinationKind, set CardSet) {
+		rest := a.solve(hand.Difference(set))
+		candidate := arrangement{
+			kind:      kind,
+			set:       set,
+			plays:     rest.plays + 1,
+			leftovers: rest.leftovers,
+		}
+		if isLeftoverSet(kind, set) {
+			candidate.leftovers++
+		}
+		if best.plays < 0 || candidate.better(best) {
+			best = candidate
+		}
+	})
+	a.memo[hand] = best
+	return best
+}
+
+// eachCombinationWithSmallest gọi yield với loại và các lá của mọi bộ trong hand có chứa lá nhỏ nhất của hand,
+// chỉ làm việc trên CardSet để không phải tạo các bộ bài
+func eachCombinationWithSmallest(hand CardSet, yield func(kind CombinationKind, set CardSet)) {
+	card := hand.Smallest()
+	first := NewCardSet(card)
+	yield(CombinationSingle, first)
+	// các lá cùng rank đều lớn hơn card
+	others := hand.Intersect(RankMask(card.rank)).Remove(card).Cards()
+	for i := 0; i < len(others); i++ {
+		yield(CombinationDubs, first.Add(others[i]))
+		for j := i + 1; j < len(others); j++ {
+			yield(CombinationTrips, first.Add(others[i]).Add(others[j]))
+		}
+	}
+	if len(others) == 3 {
+		yield(CombinationQuads, hand.Intersect(RankMask(card.rank)))
+	}
+	if card.rank == Two {
+		return
+	}
+	// sảnh bắt đầu bằng card, mỗi rank sau lấy một lá
+	var extend func(set CardSet, rank Rank, length int)
+	extend = func(set CardSet, rank Rank, length int) {
+		if length >= 3 {
+			yield(CombinationSequence, set)
+		}
+		if rank > Ace {
+			return
+		}
+		for _, c := range hand.Intersect(RankMask(rank)).Cards() {
+			extend(set.Add(c), rank+1, length+1)
+		}
+	}
+	extend(first, card.rank+1, 1)
+	// đôi thông bắt đầu bằng một đôi chứa card
+	kinds := []CombinationKind{CombinationTwoConsecutivePairs, CombinationThreeConsecutivePairs, CombinationFourConsecutivePairs}
+	var pairs func(set CardSet, rank Rank, length int)
+	pairs = func(set CardSet, rank Rank, length int) {
+		if length >= 2 {
+			yield(kinds[length-2], set)
+		}
+		if length == 4 || rank > Ace {
+			return
+		}
+		list := hand.Intersect(RankMask(rank)).Cards()
+		for i := 0; i < len(list); i++ {
+			for j := i + 1; j < len(list); j++ {
+				pairs(set.Add(list[i]).Add(list[j]), rank+1, length+1)
+			}
+		}
+	}
+	for _, c := range others {
+		pairs(first.Add(c), card.rank+1, 1)
+	}
+}
+
+// isolatedCards trả về các lá không có lá cùng rank và không nằm trong sảnh nào của hand
+func isolatedCards(hand CardSet) CardSet {
+	isolated := CardSet(0)
+	for rank := Three; rank <= Two; rank++ {
+		if hand.CountRank(rank) != 1 {
+			continue
+		}
+		// số rank liên tiếp có bài ở hai bên rank, không tính 2
+		run := 1
+		for r := rank - 1; rank != Two && r >= Three && hand.Overlaps(RankMask(r)); r-- {
+			run++
+		}
+		for r := rank + 1; rank != Two && r <= Ace && hand.Overlaps(RankMask(r)); r++ {
+			run++
+		}
+		if rank == Two || run < 3 {
+			isolated = isolated.Union(hand.Intersect(RankMask(rank)))
+		}
+	}
+	return isolated
+}
+
+// isLeftoverSet trả về true nếu bộ là lá lẻ hoặc đôi nhỏ hơn 2
+func isLeftoverSet(kind CombinationKind, set CardSet) bool {
+	return (kind == CombinationSingle || kind == CombinationDubs) && !set.Overlaps(RankMask(Two))
+}
+
+func scoreArrangement(hand CardSet, arrangement *HandArrangement) HandScore {
+	score := HandScore{
+		Cards:     float64(hand.Len()) * FactorNormalSingleCard,
+		Plays:     float64(arrangement.Plays) * FactorPlay,
+		Leftovers: float64(arrangement.Leftovers) * FactorLeftover,
+	}
+	for _, card := range hand.Intersect(RankMask(Two)).Cards() {
+		if card.suit < Diamond {
+			score.Twos += FactorBlack2sCard
+		} else {
+			score.Twos += FactorRed2sCard
+		}
+	}
+	for _, combination := range arrangement.Combinations {
+		switch combination.Kind() {
+		case CombinationThreeConsecutivePairs:
+			score.Bombs += FactorThreePairs
+		case CombinationQuads:
+			score.Bombs += FactorQuads
+		case CombinationFourConsecutivePairs:
+			score.Bombs += FactorFourPairs
+		}
+	}
+	return score
+}
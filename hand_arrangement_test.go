@@ -0,0 +1,26 @@
+package tienlen_bot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHandScoreCacheGivesThePlayerScores(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		config := NewDefaultGameConfig(4)
+		config.Seed = seed
+		game := createGame(config)
+		game.PlayRandomUntilEnd()
+
+		cached := game.Copy().(*LocalGame)
+		cached.scores = newHandScoreCache()
+		if got, want := cached.GetReward(), game.Copy().GetReward(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("seed %d: reward %v with the cache, %v without", seed, got, want)
+		}
+		for i := 0; i < game.GetMaxPlayerNumber(); i++ {
+			if got, want := cached.scores.score(game.GetPlayerAt(i).GetHand()), game.GetPlayerAt(i).GetScore(); got != want {
+				t.Fatalf("seed %d seat %d: cached score %v, player score %v", seed, i, got, want)
+			}
+		}
+	}
+}
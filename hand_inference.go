@@ -0,0 +1,269 @@
+package tienlen_bot
+
+import "math/rand"
+
+const (
+	// PassFactor is the likelihood ratio applied to an unseen card when a seat passes
+	// while the card could have been part of a combination defeating the last dealt one.
+	// It is not 0 because players keep strong cards back on purpose.
+	PassFactor float64 = 0.4
+	// PlayOverFactor is the likelihood ratio applied to an unseen single or dubs that
+	// would have defeated the target but is lower than what the seat played instead
+	PlayOverFactor float64 = 0.7
+	// sinkhornIterations là số lần chuẩn hóa hàng, cột của bảng xác suất
+	sinkhornIterations = 50
+)
+
+// HandInference estimates for one observer the probability that each opponent holds
+// each unseen card, reading the table like an experienced player:
+//
+//   - a pass on a combination makes the cards that would have defeated it less likely
+//     for the seat that passed, and a pass on a 2 or a bomb makes the bombs chopping it less likely;
+//   - a single or dubs played over a lower one makes the lower ways to defeat it less likely;
+//   - played and chopping cards leave the table.
+//
+// Passes on sequences say little about a hand and are ignored.
+// The estimates are normalized so that every unseen card is held by exactly one opponent
+// and every opponent holds as many cards as it has left.
+type HandInference struct {
+	observer int
+	rules    RuleSet
+	// lá của người quan sát và các lá đã đánh
+	hand    CardSet
+	played  CardSet
+	lengths []int
+	// weights[seat][bit] là likelihood chưa chuẩn hóa, probabilities được tính lại khi cần
+	weights       [][52]float64
+	probabilities [][52]float64
+	dirty         bool
+	target        Combination
+}
+
+// NewHandInference creates the inference of observerIndex for game and observes
+// every event of the game so far. Only public events are read, the deal of the
+// other seats is ignored.
+func NewHandInference(game Game, observerIndex int) *HandInference {
+	seats := game.GetMaxPlayerNumber()
+	observer := game.GetPlayerAt(observerIndex)
+	i := &HandInference{
+		observer: observerIndex,
+		rules:    game.GetRules(),
+		hand:     NewCardSet(observer.GetOriginalCards()...),
+		lengths:  make([]int, seats),
+		weights:  make([][52]float64, seats),
+		dirty:    true,
+	}
+	for seat := range i.weights {
+		for bit := range i.weights[seat] {
+			i.weights[seat][bit] = 1
+		}
+	}
+	for _, event := range game.GetEvents() {
+		i.Observe(event)
+	}
+	for seat := 0; seat < seats; seat++ {
+		i.lengths[seat] = game.GetPlayerAt(seat).GetCardsLength()
+	}
+	i.hand = NewCardSet(observer.GetRemainingCards()...)
+	// bàn tạo từ Position không có lịch sử các lá đã đánh, lấy lại từ game
+	i.played = i.played.Union(NewCardSet(game.GetPlayedCards()...))
+	i.normalize()
+	return i
+}
+
+// Observe updates the estimates with one more event of the game
+func (i *HandInference) Observe(event Event) {
+	if event.HandSizes != nil {
+		copy(i.lengths, event.HandSizes)
+	}
+	switch event.Kind {
+	case EventMove:
+		cards := NewCardSet(event.Combination.Cards()...)
+		if event.PlayerIndex != i.observer && i.target != nil {
+			i.observePlayOver(event.PlayerIndex, event.Combination)
+		}
+		i.played = i.played.Union(cards)
+		i.hand = i.hand.Difference(cards)
+		i.target = event.Combination
+	case EventPass:
+		if event.PlayerIndex != i.observer && i.target != nil {
+			i.observePass(event.PlayerIndex)
+		}
+	case EventRoundReset, EventEnd:
+		i.target = nil
+	}
+	i.dirty = true
+}
+
+// Probability returns the probability that seat holds card, 0 for the observer's
+// own cards, the played cards and the observer's seat
+func (i *HandInference) Probability(seat int, card *Card) float64 {
+	i.normalize()
+	return i.probabilities[seat][cardIndex(card)]
+}
+
+// Unseen returns the cards the opponents share
+func (i *HandInference) Unseen() CardSet {
+	return FullDeck.Difference(i.hand).Difference(i.played)
+}
+
+// Sample draws the hands of the opponents from the estimates, the observer's hand is nil.
+// Every hand has the number of cards its seat has left.
+// Probability and Sample only read the inference until the next Observe,
+// so several goroutines can sample it at the same time.
+func (i *HandInference) Sample(random *rand.Rand) [][]*Card {
+	i.normalize()
+	hands := make([][]*Card, len(i.lengths))
+	remaining := make([]int, len(i.lengths))
+	for seat := range i.lengths {
+		if seat != i.observer {
+			remaining[seat] = i.lengths[seat]
+			hands[seat] = make([]*Card, 0, i.lengths[seat])
+		}
+	}
+	unseen := i.Unseen().Cards()
+	random.Shuffle(len(unseen), func(a, b int) {
+		unseen[a], unseen[b] = unseen[b], unseen[a]
+	})
+	weights := make([]float64, len(i.lengths))
+	for _, card := range unseen {
+		total := 0.0
+		for seat := range weights {
+			weights[seat] = 0
+			if remaining[seat] > 0 {
+				// chia cho số lá ban đầu để ghế còn nhiều chỗ trống được ưu tiên, tránh dồn lá về cuối
+				weights[seat] = i.probabilities[seat][cardIndex(card)] * float64(remaining[seat]) / float64(i.lengths[seat])
+			}
+			total += weights[seat]
+		}
+		seat := -1
+		if total > 0 {
+			x := random.Float64() * total
+			for s := range weights {
+				if weights[s] > 0 {
+					seat = s
+					if x -= weights[s]; x < 0 {
+						break
+					}
+				}
+			}
+		} else {
+			seats := []int{}
+			for s := range remaining {
+				if remaining[s] > 0 {
+					seats = append(seats, s)
+				}
+			}
+			if len(seats) > 0 {
+				seat = seats[random.Intn(len(seats))]
+			}
+		}
+		if seat < 0 {
+			// số lá chưa lộ diện nhiều hơn số lá các ghế còn lại
+			break
+		}
+		hands[seat] = append(hands[seat], card)
+		remaining[seat]--
+	}
+	return hands
+}
+
+// observePass giảm khả năng seat giữ các lá có thể chặt được target
+func (i *HandInference) observePass(seat int) {
+	unseen := i.Unseen()
+	beating := CardSet(0)
+	collect := func(combination Combination) bool {
+		if i.rules.Defeats(combination, i.target) {
+			beating = beating.Union(NewCardSet(combination.Cards()...))
+		}
+		return true
+	}
+	if i.target.Kind() != CombinationSequence {
+		eachOfKind(unseen, i.target.Kind(), 0, collect)
+	}
+	if isBomb(i.target) || i.target.Cards()[0].rank == Two {
+		for _, kind := range []CombinationKind{
+			CombinationThreeConsecutivePairs,
+			CombinationQuads,
+			CombinationFourConsecutivePairs,
+		} {
+			eachOfKind(unseen, kind, 0, collect)
+		}
+	}
+	i.scale(seat, beating, PassFactor)
+}
+
+// observePlayOver giảm khả năng seat giữ các lá đơn, đôi chặt được target nhưng nhỏ hơn bộ seat đã đánh
+func (i *HandInference) observePlayOver(seat int, combination Combination) {
+	kind := combination.Kind()
+	if kind != i.target.Kind() || (kind != CombinationSingle && kind != CombinationDubs) {
+		return
+	}
+	lower := CardSet(0)
+	eachOfKind(i.Unseen(), kind, 0, func(c Combination) bool {
+		if i.rules.Defeats(c, i.target) && !i.rules.Defeats(c, combination) {
+			lower = lower.Union(NewCardSet(c.Cards()...))
+		}
+		return true
+	})
+	i.scale(seat, lower, PlayOverFactor)
+}
+
+func (i *HandInference) scale(seat int, cards CardSet, factor float64) {
+	for _, card := range cards.Cards() {
+		i.weights[seat][cardIndex(card)] *= factor
+	}
+}
+
+// normalize tính probabilities từ weights bằng thuật toán Sinkhorn: lần lượt chuẩn hóa
+// để mỗi lá chưa lộ diện có tổng xác suất 1 và mỗi ghế có tổng bằng số lá còn lại
+func (i *HandInference) normalize() {
+	if !i.dirty {
+		return
+	}
+	i.dirty = false
+	seats := len(i.lengths)
+	i.probabilities = make([][52]float64, seats)
+	unseen := i.Unseen().Cards()
+	for seat := 0; seat < seats; seat++ {
+		if seat == i.observer || i.lengths[seat] == 0 {
+			continue
+		}
+		for _, card := range unseen {
+			index := cardIndex(card)
+			i.probabilities[seat][index] = i.weights[seat][index]
+		}
+	}
+	for iteration := 0; iteration < sinkhornIterations; iteration++ {
+		for _, card := range unseen {
+			index := cardIndex(card)
+			total := 0.0
+			for seat := 0; seat < seats; seat++ {
+				total += i.probabilities[seat][index]
+			}
+			if total == 0 {
+				continue
+			}
+			for seat := 0; seat < seats; seat++ {
+				i.probabilities[seat][index] /= total
+			}
+		}
+		for seat := 0; seat < seats; seat++ {
+			total := 0.0
+			for _, card := range unseen {
+				total += i.probabilities[seat][cardIndex(card)]
+			}
+			if total == 0 {
+				continue
+			}
+			for _, card := range unseen {
+				i.probabilities[seat][cardIndex(card)] *= float64(i.lengths[seat]) / total
+			}
+		}
+	}
+}
+
+// cardIndex là vị trí bit của card trong CardSet
+func cardIndex(card *Card) int {
+	return int(card.rank)*4 + int(card.suit)
+}
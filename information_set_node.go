@@ -0,0 +1,213 @@
+package tienlen_bot
+
+import (
+	"fmt"
+	"math"
+)
+
+// InformationSetNode is a node of a single observer information-set MCTS tree.
+// The tree is shared by every determinization of the game, so a node does not keep
+// a fixed list of unexplored combinations: the legal moves are read from the
+// determinized game on each visit instead.
+type InformationSetNode struct {
+	parent             *InformationSetNode
+	combination        Combination
+	children           []*InformationSetNode
+	reward             Reward
+	visit              int
+	availability       int
+	currentPlayerIndex int
+	C                  float64
+	K                  float64
+}
+
+func NewInformationSetNode(parent *InformationSetNode, combination Combination, playerIndex int, maxNumberOfPlayers int) Node {
+	node := &InformationSetNode{
+		parent:             parent,
+		combination:        combination,
+		children:           []*InformationSetNode{},
+		reward:             NewReward(maxNumberOfPlayers),
+		visit:              0,
+		availability:       1,
+		currentPlayerIndex: playerIndex,
+		C:                  math.Sqrt(2),
+		K:                  0,
+	}
+	if parent != nil {
+		node.C = parent.C
+		node.K = parent.K
+	}
+	return node
+}
+
+func (l *InformationSetNode) Select(game Game) Node {
+	if game.IsEnd() {
+		return l
+	}
+	moves := availableMoves(game)
+	children := make([]*InformationSetNode, len(moves))
+	for i := range moves {
+		children[i] = l.findChild(moves[i])
+		if children[i] == nil {
+			return l
+		}
+	}
+	var maxScore float64 = -100000000
+	selected := -1
+	for i, child := range children {
+		child.availability++
+		uct := child.GetUCT()
+		if uct > maxScore {
+			maxScore = uct
+			selected = i
+		}
+	}
+	if selected < 0 {
+		return l
+	}
+	// the child keeps the combination of the determinization that created it,
+	// so move with the instance which belongs to this game
+	game.Move(moves[selected])
+	return children[selected].Select(game)
+}
+
+func (l *InformationSetNode) Expand(game Game) Node {
+	if game.IsEnd() {
+		return l
+	}
+	untried := []Combination{}
+	for _, combination := range availableMoves(game) {
+		if l.findChild(combination) == nil {
+			untried = append(untried, combination)
+		}
+	}
+	if len(untried) == 0 {
+		return l
+	}
+	combination := untried[game.GetRandom().Intn(len(untried))]
+	player := game.GetCurrentPlayerIndex()
+	game.Move(combination)
+	node := NewInformationSetNode(l, combination, player, game.GetMaxPlayerNumber()).(*InformationSetNode)
+	l.children = append(l.children, node)
+	return node
+}
+
+func (l *InformationSetNode) Simulate(game Game) Reward {
+	game.PlayRandomUntilEnd()
+	return game.GetReward()
+}
+
+func (l *InformationSetNode) BackPropagation(reward Reward) {
+	l.reward.AddReward(reward)
+	l.visit++
+	if l.parent != nil {
+		l.parent.BackPropagation(reward)
+	}
+}
+
+func (l *InformationSetNode) GetMostVisitedChildCombination() Combination {
+	mostVisitCount := 0
+	var mostVisitedNode *InformationSetNode
+	for _, child := range l.children {
+		if child.visit > mostVisitCount {
+			mostVisitCount = child.visit
+			mostVisitedNode = child
+		}
+	}
+	if mostVisitedNode == nil {
+		return nil
+	}
+	return mostVisitedNode.combination
+}
+
+// GetUCT uses the number of times the node was available for selection instead of
+// the parent visit count, since a move is not legal in every determinization.
+func (l *InformationSetNode) GetUCT() float64 {
+	exploit := l.reward.GetScoreOfPlayer(l.currentPlayerIndex) / float64(l.visit)
+	discover := l.C * math.Sqrt(math.Log(float64(l.availability))/float64(l.visit))
+	balance := l.K / (l.K + float64(l.visit))
+	return exploit + discover + balance
+}
+
+func (l *InformationSetNode) GetVisit() int {
+	return l.visit
+}
+
+func (l *InformationSetNode) GetCombination() Combination {
+	return l.combination
+}
+
+func (l *InformationSetNode) PrintAsTree(space string) {
+	for _, node := range l.children {
+		node.PrintAsTree(space + "    |")
+	}
+}
+
+func (l *InformationSetNode) PrintAllChildren() {
+	println(l.String())
+}
+
+func (l *InformationSetNode) SetCFactor(c float64) {
+	l.C = c
+}
+
+func (l *InformationSetNode) GetCFactor() float64 {
+	return l.C
+}
+
+func (l *InformationSetNode) GetReward() Reward {
+	return l.reward
+}
+
+func (l *InformationSetNode) SetKFactor(k float64) {
+	l.K = k
+}
+
+func (l *InformationSetNode) GetChildren() []Node {
+	children := make([]Node, len(l.children))
+	for i := range l.children {
+		children[i] = l.children[i]
+	}
+	return children
+}
+
+func (l *InformationSetNode) Detach() {
+	l.parent = nil
+}
+
+func (l *InformationSetNode) String() string {
+	info := ""
+	for _, node := range l.children {
+		info += fmt.Sprintf("%-40s", "Node:   "+node.combination.String())
+		info += "|"
+		info += fmt.Sprintf("%-20s", fmt.Sprintf("Visit:  %d", node.visit))
+		info += "|"
+		info += fmt.Sprintf("%-30s", fmt.Sprintf("Reward: %+v", node.reward))
+		info += "\n"
+	}
+	return info
+}
+
+func (l *InformationSetNode) findChild(combination Combination) *InformationSetNode {
+	for _, child := range l.children {
+		if child.combination.Equals(combination) {
+			return child
+		}
+	}
+	return nil
+}
+
+// availableMoves trả về toàn bộ nước đi hợp lệ của người chơi hiện tại, kể cả bỏ lượt
+// nếu còn 1 bộ đánh hết bài thì chỉ trả về bộ đó
+func availableMoves(game Game) []Combination {
+	list := game.AllAvailableCombinations()
+	if combination := finishingCombination(list, game.GetCurrentPlayer().GetCardsLength()); notNil(combination) {
+		return []Combination{combination}
+	}
+	moves := make([]Combination, len(list), len(list)+1)
+	copy(moves, list)
+	if game.GetCurrentPlayerIndex() != game.GetPreviousPlayerIndex() {
+		moves = append(moves, NewPass())
+	}
+	return moves
+}
@@ -0,0 +1,106 @@
+package tienlen_bot
+
+// InstantWinPattern is a hand which wins as soon as it is dealt (tới trắng)
+type InstantWinPattern uint8
+
+const (
+	InstantWinNone InstantWinPattern = iota
+	// InstantWinFourTwos is a hand holding the four 2s
+	InstantWinFourTwos
+	// InstantWinSixPairs is a hand made of six pairs, quads count as two pairs
+	InstantWinSixPairs
+	// InstantWinDragon is a hand holding a sequence from 3 to A
+	InstantWinDragon
+	// InstantWinFiveConsecutivePairs is a hand holding five consecutive pairs without 2
+	InstantWinFiveConsecutivePairs
+	// InstantWinFourTrips is a hand holding four trips
+	InstantWinFourTrips
+)
+
+func (p InstantWinPattern) String() string {
+	switch p {
+	case InstantWinNone:
+		return "none"
+	case InstantWinFourTwos:
+		return "four twos"
+	case InstantWinSixPairs:
+		return "six pairs"
+	case InstantWinDragon:
+		return "dragon"
+	case InstantWinFiveConsecutivePairs:
+		return "five consecutive pairs"
+	case InstantWinFourTrips:
+		return "four trips"
+	default:
+		return "undefined"
+	}
+}
+
+// AllInstantWinPatterns returns every instant win pattern, ordered by priority
+func AllInstantWinPatterns() []InstantWinPattern {
+	return []InstantWinPattern{
+		InstantWinDragon,
+		InstantWinFourTwos,
+		InstantWinFiveConsecutivePairs,
+		InstantWinSixPairs,
+		InstantWinFourTrips,
+	}
+}
+
+// DetectInstantWin returns the first pattern of patterns the cards make,
+// InstantWinNone if they make none of them
+func DetectInstantWin(cards []*Card, patterns []InstantWinPattern) InstantWinPattern {
+	count := make([]int, 13)
+	for _, card := range cards {
+		count[card.rank]++
+	}
+	for _, pattern := range patterns {
+		if matchInstantWin(count, pattern) {
+			return pattern
+		}
+	}
+	return InstantWinNone
+}
+
+// matchInstantWin kiểm tra số lượng lá của từng rank có tạo thành pattern hay không
+func matchInstantWin(count []int, pattern InstantWinPattern) bool {
+	switch pattern {
+	case InstantWinFourTwos:
+		return count[Two] == 4
+	case InstantWinSixPairs:
+		pairs := 0
+		for _, c := range count {
+			pairs += c / 2
+		}
+		return pairs >= 6
+	case InstantWinDragon:
+		for rank := Three; rank <= Ace; rank++ {
+			if count[rank] == 0 {
+				return false
+			}
+		}
+		return true
+	case InstantWinFiveConsecutivePairs:
+		consecutive := 0
+		for rank := Three; rank <= Ace; rank++ {
+			if count[rank] < 2 {
+				consecutive = 0
+				continue
+			}
+			consecutive++
+			if consecutive == 5 {
+				return true
+			}
+		}
+		return false
+	case InstantWinFourTrips:
+		trips := 0
+		for _, c := range count {
+			if c >= 3 {
+				trips++
+			}
+		}
+		return trips >= 4
+	}
+	return false
+}
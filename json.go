@@ -0,0 +1,478 @@
+package tienlen_bot
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Cards are written as their string like "10♥", combinations as an object
+// with a kind discriminator and their cards like {"kind":"dubs","cards":["7♠","7♥"]}.
+
+func (c *Card) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+func (c *Card) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	card, err := TryParseCard(s)
+	if err != nil {
+		return err
+	}
+	*c = *card
+	return nil
+}
+
+func (p InstantWinPattern) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *InstantWinPattern) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for _, pattern := range append(AllInstantWinPatterns(), InstantWinNone) {
+		if pattern.String() == s {
+			*p = pattern
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown instant win pattern %s", s)
+}
+
+type combinationJSON struct {
+	Kind  string  `json:"kind"`
+	Cards []*Card `json:"cards"`
+}
+
+func marshalCombination(combination Combination) ([]byte, error) {
+	return json.Marshal(&combinationJSON{
+		Kind:  kindToken(combination.Kind()),
+		Cards: combination.Cards(),
+	})
+}
+
+// UnmarshalCombination reads a combination of any kind written by its MarshalJSON method
+func UnmarshalCombination(data []byte) (Combination, error) {
+	var c combinationJSON
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	if c.Kind == kindToken(CombinationPass) {
+		return NewPass(), nil
+	}
+	for kind := CombinationSingle; kind < CombinationPass; kind++ {
+		if kindToken(kind) == c.Kind {
+			return ParseCombination(c.Cards, kind)
+		}
+	}
+	return nil, fmt.Errorf("%w kind %s", ErrInvalidCombination, c.Kind)
+}
+
+// marshalOptionalCombination trả về nil nếu combination là nil
+func marshalOptionalCombination(combination Combination) (json.RawMessage, error) {
+	if isNil(combination) {
+		return nil, nil
+	}
+	return json.Marshal(combination)
+}
+
+// unmarshalOptionalCombination trả về nil nếu data trống hoặc là null
+func unmarshalOptionalCombination(data json.RawMessage) (Combination, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	return UnmarshalCombination(data)
+}
+
+// unmarshalCombinationKind đọc bộ bài và kiểm tra đúng loại kind
+func unmarshalCombinationKind(data []byte, kind CombinationKind) (Combination, error) {
+	combination, err := UnmarshalCombination(data)
+	if err != nil {
+		return nil, err
+	}
+	if combination.Kind() != kind {
+		return nil, fmt.Errorf("%w %s instead of %s", ErrInvalidCombination, combination.Kind(), kind)
+	}
+	return combination, nil
+}
+
+func (s *SingleCard) MarshalJSON() ([]byte, error) {
+	return marshalCombination(s)
+}
+
+func (s *SingleCard) UnmarshalJSON(data []byte) error {
+	combination, err := unmarshalCombinationKind(data, CombinationSingle)
+	if err == nil {
+		*s = *combination.(*SingleCard)
+	}
+	return err
+}
+
+func (d *Dubs) MarshalJSON() ([]byte, error) {
+	return marshalCombination(d)
+}
+
+func (d *Dubs) UnmarshalJSON(data []byte) error {
+	combination, err := unmarshalCombinationKind(data, CombinationDubs)
+	if err == nil {
+		*d = *combination.(*Dubs)
+	}
+	return err
+}
+
+func (t *Trips) MarshalJSON() ([]byte, error) {
+	return marshalCombination(t)
+}
+
+func (t *Trips) UnmarshalJSON(data []byte) error {
+	combination, err := unmarshalCombinationKind(data, CombinationTrips)
+	if err == nil {
+		*t = *combination.(*Trips)
+	}
+	return err
+}
+
+func (q *Quads) MarshalJSON() ([]byte, error) {
+	return marshalCombination(q)
+}
+
+func (q *Quads) UnmarshalJSON(data []byte) error {
+	combination, err := unmarshalCombinationKind(data, CombinationQuads)
+	if err == nil {
+		*q = *combination.(*Quads)
+	}
+	return err
+}
+
+func (s *Sequence) MarshalJSON() ([]byte, error) {
+	return marshalCombination(s)
+}
+
+func (s *Sequence) UnmarshalJSON(data []byte) error {
+	combination, err := unmarshalCombinationKind(data, CombinationSequence)
+	if err == nil {
+		*s = *combination.(*Sequence)
+	}
+	return err
+}
+
+func (t *TwoConsecutivePairs) MarshalJSON() ([]byte, error) {
+	return marshalCombination(t)
+}
+
+func (t *TwoConsecutivePairs) UnmarshalJSON(data []byte) error {
+	combination, err := unmarshalCombinationKind(data, CombinationTwoConsecutivePairs)
+	if err == nil {
+		*t = *combination.(*TwoConsecutivePairs)
+	}
+	return err
+}
+
+func (t *ThreeConsecutivePairs) MarshalJSON() ([]byte, error) {
+	return marshalCombination(t)
+}
+
+func (t *ThreeConsecutivePairs) UnmarshalJSON(data []byte) error {
+	combination, err := unmarshalCombinationKind(data, CombinationThreeConsecutivePairs)
+	if err == nil {
+		*t = *combination.(*ThreeConsecutivePairs)
+	}
+	return err
+}
+
+func (f *FourConsecutivePairs) MarshalJSON() ([]byte, error) {
+	return marshalCombination(f)
+}
+
+func (f *FourConsecutivePairs) UnmarshalJSON(data []byte) error {
+	combination, err := unmarshalCombinationKind(data, CombinationFourConsecutivePairs)
+	if err == nil {
+		*f = *combination.(*FourConsecutivePairs)
+	}
+	return err
+}
+
+func (p *Pass) MarshalJSON() ([]byte, error) {
+	return marshalCombination(p)
+}
+
+func (p *Pass) UnmarshalJSON(data []byte) error {
+	_, err := unmarshalCombinationKind(data, CombinationPass)
+	return err
+}
+
+type turnJSON struct {
+	PlayerIndex int             `json:"player"`
+	Combination json.RawMessage `json:"combination"`
+}
+
+func (t Turn) MarshalJSON() ([]byte, error) {
+	combination, err := json.Marshal(t.Combination)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&turnJSON{PlayerIndex: t.PlayerIndex, Combination: combination})
+}
+
+func (t *Turn) UnmarshalJSON(data []byte) error {
+	var j turnJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	combination, err := UnmarshalCombination(j.Combination)
+	if err != nil {
+		return err
+	}
+	t.PlayerIndex = j.PlayerIndex
+	t.Combination = combination
+	return nil
+}
+
+type gameConfigurationJSON struct {
+	Passed               []bool              `json:"passed"`
+	MaxPlayer            int                 `json:"maxPlayer"`
+	PreviousPlayerIndex  int                 `json:"previousPlayerIndex"`
+	CurrentPlayerIndex   int                 `json:"currentPlayerIndex"`
+	LastDealtCombination json.RawMessage     `json:"lastDealtCombination,omitempty"`
+	Rape                 bool                `json:"rape"`
+	IsFirstTurn          bool                `json:"isFirstTurn"`
+	UseHeuristic         bool                `json:"useHeuristic"`
+	Seed                 int64               `json:"seed"`
+	Rules                string              `json:"rules,omitempty"`
+	InstantWins          []InstantWinPattern `json:"instantWins"`
+	Penalties            *PenaltyTable       `json:"penalties,omitempty"`
+	PlayUntilRanked      bool                `json:"playUntilRanked"`
+}
+
+// MarshalJSON writes the configuration, Rules is written by its name so only
+// the rule sets of this package can be read back
+func (g *GameConfiguration) MarshalJSON() ([]byte, error) {
+	j := &gameConfigurationJSON{
+		Passed:              g.Passed,
+		MaxPlayer:           g.MaxPlayer,
+		PreviousPlayerIndex: g.PreviousPlayerIndex,
+		CurrentPlayerIndex:  g.CurrentPlayerIndex,
+		Rape:                g.Rape,
+		IsFirstTurn:         g.IsFirstTurn,
+		UseHeuristic:        g.UseHeuristic,
+		Seed:                g.Seed,
+		InstantWins:         g.InstantWins,
+		Penalties:           g.Penalties,
+		PlayUntilRanked:     g.PlayUntilRanked,
+	}
+	if notNil(g.Rules) {
+		j.Rules = g.Rules.Name()
+	}
+	combination, err := marshalOptionalCombination(g.LastDealtCombination)
+	if err != nil {
+		return nil, err
+	}
+	j.LastDealtCombination = combination
+	return json.Marshal(j)
+}
+
+func (g *GameConfiguration) UnmarshalJSON(data []byte) error {
+	var j gameConfigurationJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*g = GameConfiguration{
+		Passed:              j.Passed,
+		MaxPlayer:           j.MaxPlayer,
+		PreviousPlayerIndex: j.PreviousPlayerIndex,
+		CurrentPlayerIndex:  j.CurrentPlayerIndex,
+		Rape:                j.Rape,
+		IsFirstTurn:         j.IsFirstTurn,
+		UseHeuristic:        j.UseHeuristic,
+		Seed:                j.Seed,
+		InstantWins:         j.InstantWins,
+		Penalties:           j.Penalties,
+		PlayUntilRanked:     j.PlayUntilRanked,
+	}
+	if j.Rules != "" {
+		if g.Rules = ruleSetByName(j.Rules); isNil(g.Rules) {
+			return fmt.Errorf("unknown rule set %s", j.Rules)
+		}
+	}
+	combination, err := unmarshalOptionalCombination(j.LastDealtCombination)
+	if err != nil {
+		return err
+	}
+	g.LastDealtCombination = combination
+	return nil
+}
+
+func (k EventKind) MarshalJSON() ([]byte, error) {
+	return json.Marshal(k.String())
+}
+
+func (k *EventKind) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for kind := EventDeal; kind <= EventEnd; kind++ {
+		if kind.String() == s {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown event kind %s", s)
+}
+
+type eventJSON struct {
+	Kind        EventKind       `json:"kind"`
+	PlayerIndex int             `json:"player"`
+	Cards       []*Card         `json:"cards,omitempty"`
+	Combination json.RawMessage `json:"combination,omitempty"`
+	Chop        *ChopEvent      `json:"chop,omitempty"`
+	Superseded  bool            `json:"superseded,omitempty"`
+	HandSizes   []int           `json:"handSizes"`
+	Bot         bool            `json:"bot,omitempty"`
+	Deal        *DealState      `json:"deal,omitempty"`
+}
+
+// MarshalJSON writes the event, a history written this way can be read back and given to Replay
+func (e Event) MarshalJSON() ([]byte, error) {
+	combination, err := marshalOptionalCombination(e.Combination)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&eventJSON{
+		Kind:        e.Kind,
+		PlayerIndex: e.PlayerIndex,
+		Cards:       e.Cards,
+		Combination: combination,
+		Chop:        e.Chop,
+		Superseded:  e.Superseded,
+		HandSizes:   e.HandSizes,
+		Bot:         e.Bot,
+		Deal:        e.Deal,
+	})
+}
+
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var j eventJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	combination, err := unmarshalOptionalCombination(j.Combination)
+	if err != nil {
+		return err
+	}
+	*e = Event{
+		Kind:        j.Kind,
+		PlayerIndex: j.PlayerIndex,
+		Cards:       j.Cards,
+		Combination: combination,
+		Chop:        j.Chop,
+		Superseded:  j.Superseded,
+		HandSizes:   j.HandSizes,
+		Bot:         j.Bot,
+		Deal:        j.Deal,
+	}
+	return nil
+}
+
+type chopEventJSON struct {
+	Payer       int             `json:"payer"`
+	Receiver    int             `json:"receiver"`
+	Amount      int             `json:"amount"`
+	Combination json.RawMessage `json:"combination"`
+	Target      json.RawMessage `json:"target"`
+}
+
+func (c ChopEvent) MarshalJSON() ([]byte, error) {
+	combination, err := marshalOptionalCombination(c.Combination)
+	if err != nil {
+		return nil, err
+	}
+	target, err := marshalOptionalCombination(c.Target)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&chopEventJSON{
+		Payer:       c.Payer,
+		Receiver:    c.Receiver,
+		Amount:      c.Amount,
+		Combination: combination,
+		Target:      target,
+	})
+}
+
+func (c *ChopEvent) UnmarshalJSON(data []byte) error {
+	var j chopEventJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	combination, err := unmarshalOptionalCombination(j.Combination)
+	if err != nil {
+		return err
+	}
+	target, err := unmarshalOptionalCombination(j.Target)
+	if err != nil {
+		return err
+	}
+	*c = ChopEvent{
+		Payer:       j.Payer,
+		Receiver:    j.Receiver,
+		Amount:      j.Amount,
+		Combination: combination,
+		Target:      target,
+	}
+	return nil
+}
+
+type dealStateJSON struct {
+	CurrentPlayerIndex   int             `json:"currentPlayerIndex"`
+	PreviousPlayerIndex  int             `json:"previousPlayerIndex"`
+	Passed               []bool          `json:"passed"`
+	LastDealtCombination json.RawMessage `json:"lastDealtCombination,omitempty"`
+	IsFirstTurn          bool            `json:"isFirstTurn"`
+	PlayedCards          []*Card         `json:"playedCards"`
+	FinishingOrder       []int           `json:"finishingOrder"`
+	NoInstantWins        bool            `json:"noInstantWins,omitempty"`
+}
+
+func (d DealState) MarshalJSON() ([]byte, error) {
+	combination, err := marshalOptionalCombination(d.LastDealtCombination)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&dealStateJSON{
+		CurrentPlayerIndex:   d.CurrentPlayerIndex,
+		PreviousPlayerIndex:  d.PreviousPlayerIndex,
+		Passed:               d.Passed,
+		LastDealtCombination: combination,
+		IsFirstTurn:          d.IsFirstTurn,
+		PlayedCards:          d.PlayedCards,
+		FinishingOrder:       d.FinishingOrder,
+		NoInstantWins:        d.NoInstantWins,
+	})
+}
+
+func (d *DealState) UnmarshalJSON(data []byte) error {
+	var j dealStateJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	combination, err := unmarshalOptionalCombination(j.LastDealtCombination)
+	if err != nil {
+		return err
+	}
+	*d = DealState{
+		CurrentPlayerIndex:   j.CurrentPlayerIndex,
+		PreviousPlayerIndex:  j.PreviousPlayerIndex,
+		Passed:               j.Passed,
+		LastDealtCombination: combination,
+		IsFirstTurn:          j.IsFirstTurn,
+		PlayedCards:          j.PlayedCards,
+		FinishingOrder:       j.FinishingOrder,
+		NoInstantWins:        j.NoInstantWins,
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package tienlen_bot
+
+// MatchConfig describes a series of hands played by the same table
+type MatchConfig struct {
+	// Game is the configuration every hand is created from, MaxPlayer is the number of seats
+	Game *GameConfiguration
+	// Bots tells which seats are played by a bot, nil means no bot
+	Bots []bool
+	// Seed makes the deals reproducible, hand i is dealt with Seed + i. 0 means a seed taken from the clock
+	Seed int64
+	// TargetScore ends the match when a seat reaches it, 0 means no score target
+	TargetScore int
+	// MaxHands ends the match after this number of hands, 0 means no hand limit
+	MaxHands int
+}
+
+// MatchState is what is needed to resume a match: the hands already settled,
+// the cumulative scores and the winner of the last hand (-1 before the first hand)
+type MatchState struct {
+	HandIndex      int
+	Scores         []int
+	PreviousWinner int
+}
+
+// Match runs a series of hands with cumulative scores. The winner of a hand leads the next one,
+// the first hand is led by the holder of the smallest card under the IsFirstTurn 3♠ rule.
+type Match struct {
+	config *MatchConfig
+	state  *MatchState
+	game   Game
+}
+
+func NewMatch(config *MatchConfig) *Match {
+	return ResumeMatch(config, &MatchState{
+		HandIndex:      0,
+		Scores:         make([]int, config.Game.MaxPlayer),
+		PreviousWinner: -1,
+	})
+}
+
+// ResumeMatch continues a match from a state returned by Match.State.
+// A seeded match deals the same hands as the match the state was saved from.
+func ResumeMatch(config *MatchConfig, state *MatchState) *Match {
+	scores := make([]int, len(state.Scores))
+	copy(scores, state.Scores)
+	return &Match{
+		config: config,
+		state: &MatchState{
+			HandIndex:      state.HandIndex,
+			Scores:         scores,
+			PreviousWinner: state.PreviousWinner,
+		},
+	}
+}
+
+// State returns a copy of the state of the match, the hand being played is not part of it
+func (m *Match) State() *MatchState {
+	scores := make([]int, len(m.state.Scores))
+	copy(scores, m.state.Scores)
+	return &MatchState{
+		HandIndex:      m.state.HandIndex,
+		Scores:         scores,
+		PreviousWinner: m.state.PreviousWinner,
+	}
+}
+
+// IsEnd reports whether the hand or the score target is reached
+func (m *Match) IsEnd() bool {
+	if m.config.MaxHands > 0 && m.state.HandIndex >= m.config.MaxHands {
+		return true
+	}
+	if m.config.TargetScore > 0 {
+		for _, score := range m.state.Scores {
+			if score >= m.config.TargetScore {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetScores returns the cumulative score of every seat
+func (m *Match) GetScores() []int {
+	return m.state.Scores
+}
+
+// GetGame returns the hand being played, nil between two hands
+func (m *Match) GetGame() Game {
+	return m.game
+}
+
+// NextHand deals the next hand. The previous hand must have been settled with EndHand.
+func (m *Match) NextHand() (Game, error) {
+	if m.IsEnd() {
+		return nil, ErrMatchEnded
+	}
+	if m.game != nil {
+		return nil, ErrHandInProgress
+	}
+	template := m.config.Game
+	seed := int64(0)
+	if m.config.Seed != 0 {
+		seed = m.config.Seed + int64(m.state.HandIndex)
+	}
+	deck := NewDeckWithSeed(seed)
+	hands := make([][]*Card, template.MaxPlayer)
+	for i := range hands {
+		hands[i] = deck.randomCards(13)
+	}
+
+	config := *template
+	config.Seed = seed
+	config.Passed = make([]bool, template.MaxPlayer)
+	config.LastDealtCombination = nil
+	leader := m.state.PreviousWinner
+	config.IsFirstTurn = leader < 0
+	if leader < 0 {
+		leader = smallestCardHolder(hands)
+	}
+	config.CurrentPlayerIndex = leader
+	config.PreviousPlayerIndex = leader
+
+	game := NewGame(&config)
+	for i := range hands {
+		player := NewPlayer()
+		player.SetBot(i < len(m.config.Bots) && m.config.Bots[i])
+		player.SetCards(hands[i])
+		game.AddPlayer(player)
+	}
+	m.game = game
+	return game, nil
+}
+
+// EndHand settles the ended hand, adds the points to the scores and returns them
+func (m *Match) EndHand() ([]int, error) {
+	if m.game == nil {
+		return nil, ErrGameNotEnded
+	}
+	deltas, err := Settle(m.game, m.config.Game.Penalties)
+	if err != nil {
+		return nil, err
+	}
+	for i := range deltas {
+		m.state.Scores[i] += deltas[i]
+	}
+	m.state.PreviousWinner = m.game.GetWinnerIndex()
+	m.state.HandIndex++
+	m.game = nil
+	return deltas, nil
+}
+
+// smallestCardHolder trả về vị trí người giữ lá nhỏ nhất (3♠ nếu đủ 4 người)
+func smallestCardHolder(hands [][]*Card) int {
+	holder := 0
+	var smallest *Card
+	for i, cards := range hands {
+		for _, card := range cards {
+			if smallest == nil || compareCard(card, smallest) < 0 {
+				smallest = card
+				holder = i
+			}
+		}
+	}
+	return holder
+}
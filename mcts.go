@@ -1,8 +1,10 @@
 package tienlen_bot
 
 import (
-	"fmt"
+	"context"
 	"math"
+	"math/rand"
+	"sync"
 	"time"
 )
 
@@ -13,6 +15,20 @@ type MctsConfig struct {
 	MinThinkingTime int64
 	MaxThinkingTime int64
 	K               float64
+	// InformationSet searches with information-set MCTS: each iteration is played on a
+	// determinization of the game, so the bot never reads the opponents' real cards
+	InformationSet  bool
+	// Workers is the number of goroutines searching in parallel, each one builds its own
+	// tree and the visit counts and rewards of the root children are merged at the end.
+	// Interactions, MinThinkingTime and MaxThinkingTime apply to every worker.
+	Workers         int
+	// Seed makes the search reproducible, worker i uses Seed + i. The same decisions are only
+	// made again when the search is stopped by Interactions, not by the thinking time.
+	// 0 means a seed taken from the clock
+	Seed            int64
+	// HandInference makes the determinizations of InformationSet draw the opponents' hands
+	// from a HandInference reading the passes and plays so far instead of uniformly
+	HandInference   bool
 }
 
 func NewDefaultMctsConfig() *MctsConfig {
@@ -23,40 +39,204 @@ func NewDefaultMctsConfig() *MctsConfig {
 		MinThinkingTime: 1000,
 		MaxThinkingTime: 2000,
 		K:               500,
+		InformationSet:  false,
+		Workers:         1,
+		Seed:            0,
+		HandInference:   false,
+	}
+}
+
+// StopReason tells why a search stopped
+type StopReason uint8
+
+const (
+	// StopNone means no search was run, the move was found without it
+	StopNone StopReason = iota
+	// StopIterations means the search used all of MctsConfig.Interactions
+	StopIterations
+	// StopTime means the search reached MctsConfig.MaxThinkingTime
+	StopTime
+	// StopConvergence means the bot was already winning after MctsConfig.MinThinkingTime
+	StopConvergence
+	// StopCancelled means the context was cancelled or its deadline was exceeded
+	StopCancelled
+)
+
+func (s StopReason) String() string {
+	switch s {
+	case StopNone:
+		return "none"
+	case StopIterations:
+		return "iterations"
+	case StopTime:
+		return "time"
+	case StopConvergence:
+		return "convergence"
+	case StopCancelled:
+		return "cancelled"
+	default:
+		return "undefined"
 	}
 }
 
 func SelectBestCombination(game Game, config *MctsConfig) Combination {
-	interactions := config.Interactions
+	return Search(context.Background(), game, config).Best
+}
+
+// SelectBestCombinationContext works like SelectBestCombination but stops searching as soon
+// as ctx is done and returns the best combination found so far, with the reason the search stopped.
+func SelectBestCombinationContext(ctx context.Context, game Game, config *MctsConfig) (Combination, StopReason) {
+	result := Search(ctx, game, config)
+	return result.Best, result.StopReason
+}
+
+// Search chooses the combination to play for the current player and returns it
+// together with the statistics of the search. Use a Searcher to keep the trees
+// between consecutive moves.
+func Search(ctx context.Context, game Game, config *MctsConfig) *SearchResult {
+	return NewSearcher(config).Search(ctx, game)
+}
+
+// selectBestCombination searches with one tree per worker, roots[i] is reused
+// when it is not nil and replaced by the tree built otherwise
+func selectBestCombination(ctx context.Context, game Game, config *MctsConfig, roots []Node) *SearchResult {
 	list := game.AllAvailableCombinations()
 	if len(list) == 0 {
-		return NewPass()
+		return newHeuristicResult(NewPass(), HeuristicNoAvailableCombination)
 	}
 	// person knowledge to make bot looks similar to a real person
-	singleCard := getBestMoveForDefeatingSingleCard(game)
+	view := NewPlayerView(game, game.GetCurrentPlayerIndex())
+	singleCard := getBestMoveForDefeatingSingleCard(view)
 	if notNil(singleCard) {
-		return singleCard
+		return newHeuristicResult(singleCard, HeuristicDefeatSingleCard)
 	}
-	singleCard = getBestMoveIfAllOtherPeopleHasOnlyOneCard(game)
+	singleCard = getBestMoveIfAllOtherPeopleHasOnlyOneCard(view)
 	if notNil(singleCard) {
-		return singleCard
+		return newHeuristicResult(singleCard, HeuristicAllOtherPeopleHasOnlyOneCard)
 	}
-	pairs := getSmallestPairsInPairsList(game)
+	pairs := getSmallestPairsInPairsList(view)
 	if notNil(pairs) {
-		return pairs
+		return newHeuristicResult(pairs, HeuristicSmallestPairsInPairsList)
+	}
+	if config.InformationSet {
+		return selectBestCombinationInformationSet(ctx, game, config, roots)
 	}
 	// monte carlo tree search algorithm
 	root := NewNode(nil, nil, -1, game)
 	if len(root.(*LocalNode).unexploredCombinations) == 1 {
-		return root.(*LocalNode).unexploredCombinations[0]
+		return newHeuristicResult(root.(*LocalNode).unexploredCombinations[0], HeuristicOnlyOneCombination)
 	}
-	root.SetCFactor(config.C)
-	root.SetKFactor(config.K)
-	startThinkingTime := currentTimeMillis()
-	for interactions > 0 && currentTimeMillis()-startThinkingTime < config.MaxThinkingTime {
-		interactions--
+	return searchParallel(ctx, game, config, roots, func(game Game) Node {
+		return NewNode(nil, nil, -1, game)
+	}, func(game Game) Game {
+		return game.Copy()
+	})
+}
+
+// selectBestCombinationInformationSet chạy information-set MCTS,
+// tất cả các lần determinize dùng chung một cây
+func selectBestCombinationInformationSet(ctx context.Context, game Game, config *MctsConfig, roots []Node) *SearchResult {
+	moves := availableMoves(game)
+	if len(moves) == 1 {
+		return newHeuristicResult(moves[0], HeuristicOnlyOneCombination)
+	}
+	observerIndex := game.GetCurrentPlayerIndex()
+	var inference *HandInference
+	if config.HandInference {
+		inference = NewHandInference(game, observerIndex)
+	}
+	return searchParallel(ctx, game, config, roots, func(game Game) Node {
+		return NewInformationSetNode(nil, nil, -1, game.GetMaxPlayerNumber())
+	}, func(game Game) Game {
+		if inference != nil {
+			return game.DeterminizeFrom(inference)
+		}
+		return game.Determinize(observerIndex)
+	})
+}
+
+// searchParallel runs one search per root at the same time. Every worker works on
+// its own copy of the game with its own rand.Rand and hand score cache, builds its tree with newRoot
+// when its root is nil, and plays each iteration on the game returned by sample.
+func searchParallel(ctx context.Context, game Game, config *MctsConfig, roots []Node, newRoot func(game Game) Node, sample func(game Game) Game) *SearchResult {
+	workers := len(roots)
+	interactions := make([]int, workers)
+	reasons := make([]StopReason, workers)
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			base := game.Copy()
+			base.SetRandom(rand.New(rand.NewSource(seed + int64(i))))
+			// các bản copy của base dùng chung một cache điểm bài của riêng worker này
+			if local, ok := base.(*LocalGame); ok {
+				local.scores = newHandScoreCache()
+			}
+			if isNil(roots[i]) {
+				roots[i] = newRoot(base)
+			}
+			roots[i].SetCFactor(config.C)
+			roots[i].SetKFactor(config.K)
+			interactions[i], reasons[i] = search(ctx, roots[i], base, config, func() Game {
+				return sample(base)
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	result := &SearchResult{
+		Children:   mergeRootChildren(roots, game.GetCurrentPlayerIndex(), game.GetMaxPlayerNumber(), config),
+		StopReason: reasons[0],
+	}
+	for i := range roots {
+		result.Playouts += interactions[i]
+		if reasons[i] == StopCancelled {
+			result.StopReason = StopCancelled
+		}
+	}
+	var best *ChildResult
+	for _, child := range result.Children {
+		if best == nil || child.Visit > best.Visit {
+			best = child
+		}
+	}
+	// bị hủy trước khi kịp chạy lượt nào thì đánh nước hợp lệ đầu tiên
+	if best == nil {
+		result.Best = availableMoves(game)[0]
+		return result
+	}
+	result.Best = best.Combination
+	result.PrincipalVariation = principalVariation(roots, best.Combination)
+	return result
+}
+
+// search runs the mcts loop on root, every iteration is played on the game returned by sample.
+// It returns the number of iterations done and the reason it stopped.
+func search(ctx context.Context, root Node, game Game, config *MctsConfig, sample func() Game) (int, StopReason) {
+	interactions := config.Interactions
+	minThinkingTime := time.Duration(config.MinThinkingTime) * time.Millisecond
+	maxThinkingTime := time.Duration(config.MaxThinkingTime) * time.Millisecond
+	startThinkingTime := time.Now()
+	for {
+		if interactions <= 0 {
+			return config.Interactions - interactions, StopIterations
+		}
+		select {
+		case <-ctx.Done():
+			return config.Interactions - interactions, StopCancelled
+		default:
+		}
+		thinkingTime := time.Since(startThinkingTime)
+		if thinkingTime >= maxThinkingTime {
+			return config.Interactions - interactions, StopTime
+		}
 		/* keep playing while the ratio of winning is less than 50% */
-		if currentTimeMillis()-startThinkingTime > config.MinThinkingTime {
+		if thinkingTime > minThinkingTime {
 			var x, y float64
 			for i := 0; i < game.GetMaxPlayerNumber(); i++ {
 				if i == game.GetCurrentPlayerIndex() {
@@ -66,28 +246,17 @@ func SelectBestCombination(game Game, config *MctsConfig) Combination {
 				}
 			}
 			if x > y {
-				break
+				return config.Interactions - interactions, StopConvergence
 			}
 		}
 		/* continue loop */
-		gameCopy := game.Copy()
+		interactions--
+		gameCopy := sample()
 		node := root.Select(gameCopy)
 		node = node.Expand(gameCopy)
 		reward := node.Simulate(gameCopy)
 		node.BackPropagation(reward)
 	}
-
-	if config.Debug {
-		println(fmt.Sprintf("MCTS %d interactions, reward: %+v, visit: %d, thinking time: %d",
-			config.Interactions-interactions, root.GetReward(), root.GetVisit(), currentTimeMillis()-startThinkingTime))
-		root.PrintAllChildren()
-	}
-
-	return root.GetMostVisitedChildCombination()
-}
-
-func currentTimeMillis() int64 {
-	return time.Now().UnixNano() / int64(time.Millisecond)
 }
 
 /*
@@ -96,8 +265,8 @@ func currentTimeMillis() int64 {
        2.1. Nếu bài user kia còn > 4 lá thì cứ đánh đôi bé trước
        2.2. Nếu user kia còn <= 4 lá thì đánh đôi K trước
 */
-func getSmallestPairsInPairsList(game Game) Combination {
-	list := game.AllAvailableCombinations()
+func getSmallestPairsInPairsList(view *PlayerView) Combination {
+	list := view.AllAvailableCombinations()
 	if len(list) != 6 {
 		return nil
 	}
@@ -118,21 +287,14 @@ func getSmallestPairsInPairsList(game Game) Combination {
 	if cardCount != 4 || dubsCount != 2 {
 		return nil
 	}
-	for i := 0; i < game.GetMaxPlayerNumber(); i++ {
-		if i == game.GetCurrentPlayerIndex() {
+	if view.CanUnseenCardsDefeat(dubs[1]) {
+		return nil
+	}
+	for i := 0; i < view.GetMaxPlayerNumber(); i++ {
+		if i == view.GetSeat() {
 			continue
 		}
-		combinations := game.GetPlayerAt(i).AllAvailableCombinations()
-		count := 0
-		for j := range combinations {
-			if combinations[j].Kind() == CombinationSingle {
-				count++
-			}
-			if combinations[j].Defeats(dubs[1]) {
-				return nil
-			}
-		}
-		if count > 4 {
+		if view.GetCardsLength(i) > 4 {
 			return dubs[0]
 		}
 	}
@@ -142,37 +304,33 @@ func getSmallestPairsInPairsList(game Game) Combination {
 // nếu bộ còn toàn cóc lẻ và bên kia không chặn được con cóc lẻ nào thì đánh lần lượt từ thấp gần nhất lên cao
 // chỉ tính nếu có ít nhất 1 người còn 2 lá trở lên
 // còn nếu tất cả mọi người đều còn 1 lá thì đánh từ cao xuống thất
-func getBestMoveForDefeatingSingleCard(game Game) Combination {
-	list := game.AllAvailableCombinations()
+func getBestMoveForDefeatingSingleCard(view *PlayerView) Combination {
+	list := view.AllAvailableCombinations()
 	if !isAllSingleCard(list) {
 		return nil
 	}
 
-	if len(game.GetCurrentPlayer().AllAvailableCombinations()) != len(list) {
+	if len(view.GetCombinations()) != len(list) {
 		return nil
 	}
 	if len(list) == 1 {
 		return list[0]
 	}
 	// kiểm tra điều kiện có ai chặt được quân nhỏ gần nhất không
-	for i := 0; i < game.GetMaxPlayerNumber(); i++ {
-		if i == game.GetCurrentPlayerIndex() || game.PlayerPassed(i) {
-			continue
-		}
-		if len(game.GetPlayerAt(i).AllAvailableCombinationsDefeat(list[1])) != 0 {
-			return nil
-		}
+	// chỉ dựa trên các lá chưa lộ diện, không nhìn bài của người khác
+	if hasOpponentInRound(view) && view.CanUnseenCardsDefeat(list[1]) {
+		return nil
 	}
 	// check trường hợp có ít nhất 1 thằng trên bàn còn nhiều hơn 1 lá thì đánh từ lá nhỏ gần nhất nếu còn nhiều
 	// hơn 2 lá
 	// hoặc đánh là nhỏ nhất nếu còn 2 lá
-	for i := 0; i < game.GetMaxPlayerNumber(); i++ {
-		if i == game.GetCurrentPlayerIndex() {
+	for i := 0; i < view.GetMaxPlayerNumber(); i++ {
+		if i == view.GetSeat() {
 			continue
 		}
-		if game.GetPlayerAt(i).GetCardsLength() > 1 {
+		if view.GetCardsLength(i) > 1 {
 			// nếu ít hơn 3 lá thì đánh là nhỏ nhất
-			if game.GetPlayerAt(game.GetCurrentPlayerIndex()).GetCardsLength() <= 2 {
+			if view.GetCardsLength(view.GetSeat()) <= 2 {
 				return list[0]
 			}
 			// nếu nhiều hơn 2 lá thì đánh là gần nhỏ nhất
@@ -185,35 +343,45 @@ func getBestMoveForDefeatingSingleCard(game Game) Combination {
 
 // nếu mọi người chỉ còn 1 lá và mình không còn bộ nào (chỉ còn quân lẻ)
 // thì đánh từ to tới nhỏ trong trường hợp là turn tự đánh ko phải turn chặt
-func getBestMoveIfAllOtherPeopleHasOnlyOneCard(game Game) Combination {
-	if game.GetCurrentPlayerIndex() != game.GetPreviousPlayerIndex() {
+func getBestMoveIfAllOtherPeopleHasOnlyOneCard(view *PlayerView) Combination {
+	if view.GetCurrentPlayerIndex() != view.GetPreviousPlayerIndex() {
 		return nil
 	}
-	if !allOtherPeopleHasOnlySingleCardLeft(game) {
+	if !allOtherPeopleHasOnlySingleCardLeft(view) {
 		return nil
 	}
 
-	list := game.GetCurrentPlayer().AllAvailableCombinations()
+	list := view.GetCombinations()
 	return list[len(list)-1]
 }
 
 // kiểm tra tất cả người khác có còn 1 lá ko
 // và mình chỉ còn toàn con lẻ không
-func allOtherPeopleHasOnlySingleCardLeft(game Game) bool {
-	for i := 0; i < game.GetMaxPlayerNumber(); i++ {
-		if i == game.GetCurrentPlayerIndex() {
-			if !isAllSingleCard(game.GetPlayerAt(i).AllAvailableCombinations()) {
+func allOtherPeopleHasOnlySingleCardLeft(view *PlayerView) bool {
+	for i := 0; i < view.GetMaxPlayerNumber(); i++ {
+		if i == view.GetSeat() {
+			if !isAllSingleCard(view.GetCombinations()) {
 				return false
 			}
 			continue
 		}
-		if game.GetPlayerAt(i).GetCardsLength() != 1 {
+		if view.GetCardsLength(i) != 1 {
 			return false
 		}
 	}
 	return true
 }
 
+// còn ít nhất 1 người khác chưa bỏ lượt trong vòng này
+func hasOpponentInRound(view *PlayerView) bool {
+	for i := 0; i < view.GetMaxPlayerNumber(); i++ {
+		if i != view.GetSeat() && !view.PlayerPassed(i) {
+			return true
+		}
+	}
+	return false
+}
+
 // đánh quân nhỏ nhất, nều bài mình chắc thắng và toàn quân lẻ
 // nếu đối phương không còn quân
 func keepSmallestCardIfSureWinAndHaveNoCombination() {
@@ -0,0 +1,228 @@
+package tienlen_bot
+
+// MoveGenerator produces the plays of a hand one at a time, straight from the cards,
+// without building every combination of the hand first. Only the kinds that can defeat
+// the target are generated, so following a single never walks the sequences of the hand.
+type MoveGenerator struct {
+	hand   CardSet
+	target Combination
+	rules  RuleSet
+}
+
+// NewMoveGenerator creates a generator for the plays of hand on target.
+// A nil target or a pass means the player leads and every combination of the hand is a play.
+// A nil rules means the Cambodian rules.
+func NewMoveGenerator(hand CardSet, target Combination, rules RuleSet) *MoveGenerator {
+	if isNil(rules) {
+		rules = defaultRules
+	}
+	if isNil(target) || target.Kind() == CombinationPass {
+		target = nil
+	}
+	return &MoveGenerator{
+		hand:   hand,
+		target: target,
+		rules:  rules,
+	}
+}
+
+// Each calls yield with every play in turn and stops as soon as yield returns false.
+// It returns false when yield stopped it.
+func (g *MoveGenerator) Each(yield func(Combination) bool) bool {
+	emit := func(combination Combination) bool {
+		if g.target != nil && !g.rules.Defeats(combination, g.target) {
+			return true
+		}
+		return yield(combination)
+	}
+	length := 0
+	if g.target != nil {
+		length = len(g.target.Cards())
+	}
+	for _, kind := range g.kinds() {
+		if !eachOfKind(g.hand, kind, length, emit) {
+			return false
+		}
+	}
+	return true
+}
+
+// All returns every play
+func (g *MoveGenerator) All() []Combination {
+	combinations := []Combination{}
+	g.Each(func(combination Combination) bool {
+		combinations = append(combinations, combination)
+		return true
+	})
+	return combinations
+}
+
+// Any reports whether there is at least one play, it stops at the first one found
+func (g *MoveGenerator) Any() bool {
+	return !g.Each(func(Combination) bool {
+		return false
+	})
+}
+
+// kinds trả về các loại bộ có thể chặt được target, hàng chặt luôn được xét
+func (g *MoveGenerator) kinds() []CombinationKind {
+	if g.target == nil {
+		return []CombinationKind{
+			CombinationSingle,
+			CombinationDubs,
+			CombinationSequence,
+			CombinationTwoConsecutivePairs,
+			CombinationTrips,
+			CombinationThreeConsecutivePairs,
+			CombinationQuads,
+			CombinationFourConsecutivePairs,
+		}
+	}
+	kinds := []CombinationKind{g.target.Kind()}
+	for _, kind := range []CombinationKind{
+		CombinationThreeConsecutivePairs,
+		CombinationQuads,
+		CombinationFourConsecutivePairs,
+	} {
+		if kind != g.target.Kind() {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// eachOfKind sinh các bộ loại kind trong hand, length là độ dài sảnh (0 là mọi độ dài)
+func eachOfKind(hand CardSet, kind CombinationKind, length int, yield func(Combination) bool) bool {
+	switch kind {
+	case CombinationSingle:
+		return eachSingle(hand, yield)
+	case CombinationDubs:
+		return eachDubs(hand, yield)
+	case CombinationTrips:
+		return eachTrips(hand, yield)
+	case CombinationQuads:
+		return eachQuads(hand, yield)
+	case CombinationSequence:
+		return eachSequence(hand, length, yield)
+	case CombinationTwoConsecutivePairs:
+		return eachConsecutivePairs(hand, 2, yield)
+	case CombinationThreeConsecutivePairs:
+		return eachConsecutivePairs(hand, 3, yield)
+	case CombinationFourConsecutivePairs:
+		return eachConsecutivePairs(hand, 4, yield)
+	}
+	return true
+}
+
+func eachSingle(hand CardSet, yield func(Combination) bool) bool {
+	for set := hand; !set.IsEmpty(); {
+		card := set.Smallest()
+		if !yield(NewSingleCard(card)) {
+			return false
+		}
+		set = set.Remove(card)
+	}
+	return true
+}
+
+func eachDubs(hand CardSet, yield func(Combination) bool) bool {
+	for rank := Three; rank <= Two; rank++ {
+		for _, dubs := range dubsOfRank(hand, rank) {
+			if !yield(dubs) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func eachTrips(hand CardSet, yield func(Combination) bool) bool {
+	for rank := Three; rank <= Two; rank++ {
+		list := hand.Intersect(RankMask(rank)).Cards()
+		for i := 0; i < len(list); i++ {
+			for j := i + 1; j < len(list); j++ {
+				for t := j + 1; t < len(list); t++ {
+					if !yield(NewTrips(list[i], list[j], list[t])) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+func eachQuads(hand CardSet, yield func(Combination) bool) bool {
+	for rank := Three; rank <= Two; rank++ {
+		if hand.CountRank(rank) == 4 {
+			list := RankMask(rank).Cards()
+			if !yield(NewQuads(list[0], list[1], list[2], list[3])) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// eachSequence sinh các sảnh trong hand, length = 0 nghĩa là mọi độ dài
+func eachSequence(hand CardSet, length int, yield func(Combination) bool) bool {
+	for start := Three; start <= Queen; start++ {
+		if !eachSequenceFrom(hand, start, length, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// eachSequenceFrom sinh các sảnh trong hand bắt đầu từ rank start
+func eachSequenceFrom(hand CardSet, start Rank, length int, yield func(Combination) bool) bool {
+	// sảnh ngắn nhất cần 3 rank liên tiếp, kiểm tra trước để không cấp phát
+	for rank := start; rank < start+3; rank++ {
+		if rank > Ace || !hand.Overlaps(RankMask(rank)) {
+			return true
+		}
+	}
+	cards := make([]*Card, 0, int(Ace-Three)+1)
+	var extend func(rank Rank) bool
+	extend = func(rank Rank) bool {
+		if len(cards) >= 3 && (length == 0 || len(cards) == length) {
+			sequence := make([]*Card, len(cards))
+			copy(sequence, cards)
+			if !yield(NewSequence(sequence)) {
+				return false
+			}
+		}
+		if rank > Ace || (length > 0 && len(cards) == length) {
+			return true
+		}
+		for _, card := range hand.Intersect(RankMask(rank)).Cards() {
+			cards = append(cards, card)
+			if !extend(rank + 1) {
+				return false
+			}
+			cards = cards[:len(cards)-1]
+		}
+		return true
+	}
+	return extend(start)
+}
+
+func eachConsecutivePairs(hand CardSet, length int, yield func(Combination) bool) bool {
+	for rank := Three; rank+Rank(length)-1 <= Ace; rank++ {
+		for _, list := range consecutiveDubs(hand, rank, length) {
+			var combination Combination
+			switch length {
+			case 2:
+				combination = NewTwoConsecutivePairs(list[0], list[1])
+			case 3:
+				combination = NewThreeConsecutivePairs(list[0], list[1], list[2])
+			default:
+				combination = NewFourConsecutivePairs(list[0], list[1], list[2], list[3])
+			}
+			if !yield(combination) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -21,6 +21,9 @@ type Node interface {
 	GetCFactor() float64
 	GetReward() Reward
 	SetKFactor(k float64)
+	GetChildren() []Node
+	// cắt node khỏi node cha để dùng làm root của cây mới
+	Detach()
 	String() string
 }
 
@@ -54,8 +57,8 @@ func NewNode(parent *LocalNode, combination Combination, playerIndex int, game G
 	}
 	list := game.AllAvailableCombinations()
 	if !game.IsEnd() {
-		if len(list) > 0 && len(list[len(list)-1].Cards()) == game.GetCurrentPlayer().GetCardsLength() {
-			node.unexploredCombinations = append(node.unexploredCombinations, list[len(list)-1])
+		if combination := finishingCombination(list, game.GetCurrentPlayer().GetCardsLength()); notNil(combination) {
+			node.unexploredCombinations = append(node.unexploredCombinations, combination)
 		} else {
 			node.unexploredCombinations = make([]Combination, len(list))
 			copy(node.unexploredCombinations, list)
@@ -66,8 +69,10 @@ func NewNode(parent *LocalNode, combination Combination, playerIndex int, game G
 				node.unexploredCombinations = append(node.unexploredCombinations, NewPass())
 			}
 			if isNil(parent) {
+				// các luật tỉa nước đi chỉ dùng những gì người chơi hiện tại được biết
+				view := NewPlayerView(game, game.GetCurrentPlayerIndex())
 				if !game.HasNoLastDealtCombination() {
-					node.keepConsecutivePairsForDefeating2(game)
+					node.keepConsecutivePairsForDefeating2(view, game.GetRandom())
 				}
 				if node.allHasOneCardLeft(game) {
 					if game.GetCurrentPlayerIndex() == game.GetPreviousPlayerIndex() {
@@ -80,10 +85,10 @@ func NewNode(parent *LocalNode, combination Combination, playerIndex int, game G
 						// xóa 2 nếu là lượt đánh không chặt
 						// và chỉ còn 1 con 2 với có ít nhất 1 con lẻ nhỏ hơn 2
 						// và không ai còn 1 lá trên bàn
-						node.remove2IfIsFirstTurn(game)
+						node.remove2IfIsFirstTurn(view)
 						// xóa 3, 4 đôi thông hoặc tứ quý nếu người chơi kia còn 2 con 1 con 2 và 1 con lẻ
 						// con lẻ nhỏ hơn ít nhất 1 con lẻ của mình
-						node.removeCombinationStrongerThan2IfTheyHave2AndOneSmallSingleCardLeft(game)
+						node.removeCombinationStrongerThan2IfTheyHave2AndOneSmallSingleCardLeft(view)
 					}
 				}
 				if node.canDefeatTheirSingleCard(game) {
@@ -120,7 +125,7 @@ func (l *LocalNode) Expand(game Game) Node {
 	if len(l.unexploredCombinations) <= 0 {
 		return l
 	}
-	randomNumber := rand.Intn(len(l.unexploredCombinations))
+	randomNumber := game.GetRandom().Intn(len(l.unexploredCombinations))
 	combination := l.removeUnexploredCombinationAt(randomNumber)
 	player := game.GetCurrentPlayerIndex()
 	game.Move(combination)
@@ -207,6 +212,31 @@ func (l *LocalNode) SetKFactor(k float64) {
 	l.K = k
 }
 
+func (l *LocalNode) GetChildren() []Node {
+	return l.children
+}
+
+func (l *LocalNode) Detach() {
+	l.parent = nil
+}
+
+// pruneAsRoot tỉa node vừa được Detach như một root mới tạo bởi NewNode ở game:
+// các nước đi root không thử bị bỏ cùng cây con của chúng, các cây con còn lại được giữ
+func (l *LocalNode) pruneAsRoot(game Game) {
+	root := NewNode(nil, l.combination, l.currentPlayerIndex, game).(*LocalNode)
+	children := []Node{}
+	unexplored := []Combination{}
+	for _, combination := range root.unexploredCombinations {
+		if child := childOf(l, combination); notNil(child) {
+			children = append(children, child)
+		} else {
+			unexplored = append(unexplored, combination)
+		}
+	}
+	l.children = children
+	l.unexploredCombinations = unexplored
+}
+
 //  xóa 3 đôi thông, 4 đôi thông và 2 đi
 func (l *LocalNode) removeStrongCombinationsIfNotNecessary(game Game) {
 	conf := game.GetConfig()
@@ -285,49 +315,39 @@ func (l *LocalNode) removeStrongCombinationsThan2IfHave2() {
 }
 
 // luôn dùng tứ quý, 3 đôi thông hoặc 4 đôi thông nếu người trước đánh 2
-func (l *LocalNode) keepConsecutivePairsForDefeating2(game Game) {
+func (l *LocalNode) keepConsecutivePairsForDefeating2(view *PlayerView, random *rand.Rand) {
 	// nếu con đánh ko phải 2 hoặc đôi 2 hoặc tam 2 thì thôi
-	if !containsRank(game.GetLastDealtCombination().Cards(), Two) {
+	if !containsRank(view.GetLastDealtCombination().Cards(), Two) {
 		return
 	}
 	// nếu bot đánh đôi 2, hoặc tam 2 mà chặn được thì chặn luôn
-	if len(game.GetLastDealtCombination().Cards()) >= 2 &&
+	if len(view.GetLastDealtCombination().Cards()) >= 2 &&
 		len(l.unexploredCombinations) >= 2 {
 		l.removePass()
 		return
 	}
 
 	//nếu bot đánh 1 con 2 lẻ
-	if game.GetLastDealtCombination().Kind() == CombinationSingle {
+	if view.GetLastDealtCombination().Kind() == CombinationSingle {
 		if !l.hasStrongCombination(l.unexploredCombinations) {
 			return
-		} else if game.GetMaxPlayerNumber() == 1 || rand.Intn(100) < 70 {
+		} else if view.GetMaxPlayerNumber() == 1 || random.Intn(100) < 70 {
 			// 70% remove 2 if not chặt turn
 			l.removeAllSingleCard()
 		}
 
-		combinations := game.GetPlayerAt(game.GetPreviousPlayerIndex()).AllAvailableCombinations()
-		contains2 := false
-		for i := range combinations {
-			if combinations[i].Kind() == CombinationSingle &&
-				combinations[i].(*SingleCard).card.rank == Two {
-				contains2 = true
-				break
-			}
-		}
-
-		// nếu người chơi trước không còn 2 thì chặn luôn
-		if !contains2 {
+		// nếu không còn con 2 nào chưa lộ diện thì người chơi trước chắc chắn không còn 2, chặn luôn
+		if view.CountUnseenRank(Two) == 0 {
 			l.removePass()
 			return
 		}
 
-		// nếu người chơi trước có 2 thì 100% chặn nếu con vừa đánh là 2 đỏ và 90% chặn nếu là 2 đen
-		card := game.GetLastDealtCombination().Cards()[0]
+		// nếu người chơi trước có thể còn 2 thì 100% chặn nếu con vừa đánh là 2 đỏ và 90% chặn nếu là 2 đen
+		card := view.GetLastDealtCombination().Cards()[0]
 		if card.suit == Heart || card.suit == Diamond {
 			l.removePass()
 		} else {
-			if rand.Intn(10) > 1 {
+			if random.Intn(10) > 1 {
 				l.removePass()
 			}
 		}
@@ -335,13 +355,13 @@ func (l *LocalNode) keepConsecutivePairsForDefeating2(game Game) {
 }
 
 // loại con 2 ra nếu turn này mình không phải chặn ai
-func (l *LocalNode) remove2IfIsFirstTurn(game Game) {
+func (l *LocalNode) remove2IfIsFirstTurn(view *PlayerView) {
 	// check lại nếu có 1 người còn 1 con thì không được loại 2
-	for i := 0; i < game.GetMaxPlayerNumber(); i++ {
-		if i == game.GetCurrentPlayerIndex() {
+	for i := 0; i < view.GetMaxPlayerNumber(); i++ {
+		if i == view.GetSeat() {
 			continue
 		}
-		if len(game.GetPlayerAt(i).AllAvailableCombinations()) == 1 {
+		if view.GetCardsLength(i) == 1 {
 			return
 		}
 	}
@@ -435,32 +455,27 @@ func (l *LocalNode) removeSingleCardIfTheyAllHaveOneCardLeft(game Game) {
 // bỏ các bộ mạnh hơn con 2 kia đi nếu bỏ đi mà vẫn có quân lẻ lớn hơn con lẻ còn lại của người kia
 // chỉ tính trường hợp 2 người chơi
 // trong turn không phải turn chặt
-func (l *LocalNode) removeCombinationStrongerThan2IfTheyHave2AndOneSmallSingleCardLeft(game Game) {
-	if game.GetMaxPlayerNumber() != 2 {
-		return
-	}
-	if game.GetPlayerAt(1 - game.GetCurrentPlayerIndex()).GetCardsLength() != 2 {
+// không nhìn bài đối phương: chỉ xét khi còn 2 chưa lộ diện, và coi con lẻ của họ
+// là lá lớn nhất (không phải 2) chưa lộ diện
+func (l *LocalNode) removeCombinationStrongerThan2IfTheyHave2AndOneSmallSingleCardLeft(view *PlayerView) {
+	if view.GetMaxPlayerNumber() != 2 {
 		return
 	}
-	com := game.GetPlayerAt(1 - game.GetCurrentPlayerIndex()).AllAvailableCombinations()
-	if len(com) != 2 {
+	if view.GetCardsLength(1 - view.GetSeat()) != 2 {
 		return
 	}
-	if com[0].Kind() != CombinationSingle || com[1].Kind() != CombinationSingle {
+	if view.CountUnseenRank(Two) == 0 {
 		return
 	}
-	if com[0].(*SingleCard).card.rank != Two && com[1].(*SingleCard).card.rank != Two {
-		return
+	var card *SingleCard
+	for _, c := range view.GetUnseenCards() {
+		if c.rank != Two {
+			card = NewSingleCard(c)
+		}
 	}
-	if com[0].(*SingleCard).card.rank == Two && com[1].(*SingleCard).card.rank == Two {
+	if card == nil {
 		return
 	}
-	var card *SingleCard
-	if com[0].(*SingleCard).card.rank == Two {
-		card = com[1].(*SingleCard)
-	} else {
-		card = com[0].(*SingleCard)
-	}
 
 	rmList := []Combination{}
 	for i := range l.unexploredCombinations {
@@ -472,7 +487,7 @@ func (l *LocalNode) removeCombinationStrongerThan2IfTheyHave2AndOneSmallSingleCa
 	}
 	rmListLen := len(rmList)
 	for i := 0; i < rmListLen; i++ {
-		rmList = append(rmList, game.GetCurrentPlayer().GetAllCombinationsHasSameAtLeastOneCardWith(rmList[i])...)
+		rmList = append(rmList, view.GetAllCombinationsHasSameAtLeastOneCardWith(rmList[i])...)
 	}
 
 	backup := make([]Combination, len(l.unexploredCombinations))
@@ -484,7 +499,7 @@ func (l *LocalNode) removeCombinationStrongerThan2IfTheyHave2AndOneSmallSingleCa
 
 	if len(l.unexploredCombinations) > 0 {
 		for i := range l.unexploredCombinations {
-			if l.unexploredCombinations[i].Defeats(card) {
+			if view.GetRules().Defeats(l.unexploredCombinations[i], card) {
 				if card.card.rank != Ace {
 					// nếu quân lẻ kia kp quân át thì bỏ các bộ đôi A, tam A ra, đánh cóc A câu 2
 					rmList = []Combination{}
@@ -19,10 +19,6 @@ func (p *Pass) Cards() []*Card {
 	return []*Card{}
 }
 
-func (p *Pass) Defeats(combination Combination) bool {
-	return false
-}
-
 func (p *Pass) Copy() Combination {
 	return p
 }
@@ -9,8 +9,8 @@ type Player interface {
 	GetOriginalCards() []*Card
 	// lấy toàn bộ các quân bài, bộ bài có thể đánh
 	AllAvailableCombinations() []Combination
-	// lấy toàn bộ bộ bài có thể đánh để chặt được bộ combination
-	AllAvailableCombinationsDefeat(combination Combination) []Combination
+	// lấy toàn bộ bộ bài có thể đánh để chặt được bộ combination theo luật rules
+	AllAvailableCombinationsDefeat(combination Combination, rules RuleSet) []Combination
 	// xóa bộ combination ra khỏi bộ bài
 	Remove(combination Combination)
 	// set init card cho người chơi
@@ -38,21 +38,45 @@ type Player interface {
 	GetCardsLength() int
 	// lấy lá có giá trị nhỏ nhất trong bộ bài
 	GetSmallestCard() *Card
-	// lấy điểm hiện tại của người chơi
+	// lấy điểm hiện tại của người chơi, là tổng điểm của GetArrangement
 	// điểm càng cao thì người chơi còn càng nhiều bài, nhiều đồ
 	GetScore() float64
+	// lấy cách chia bài trên tay thành ít bộ nhất
+	GetArrangement() *HandArrangement
 	// lấy tất cả bộ có chung ít nhất 1 lá với bộ combination
 	GetAllCombinationsHasSameAtLeastOneCardWith(combination Combination) []Combination
+	// lấy các lá bài còn lại trên tay người chơi, sắp xếp từ nhỏ đến lớn
+	GetRemainingCards() []*Card
+	// lấy các lá bài còn lại trên tay người chơi dưới dạng CardSet
+	GetHand() CardSet
+	// tìm trong bộ bài bộ giống với combination, trả về nil nếu không có
+	GetCombination(combination Combination) Combination
+	// trả lại các bộ đã bị xóa bởi lần gọi Remove gần nhất, đúng vị trí cũ
+	UndoRemove()
 }
 
 type LocalPlayer struct {
-	index        int
-	isBot        bool
-	cards        []*Card
+	index int
+	isBot bool
+	cards []*Card
+	hand  CardSet
+	// các bộ bài được sinh khi cần lần đầu, sets[i] là các lá của combinations[i]
+	built        bool
 	combinations []Combination
-	connectors   map[Combination][]Combination
-	cardsLength  int
-	score  float64
+	sets         []CardSet
+	// cách chia bài của hand, tính khi cần
+	arrangement *HandArrangement
+	removals    []removal
+}
+
+// removal lưu lại một lần Remove để có thể hoàn tác,
+// built là false nếu lúc Remove các bộ bài chưa được sinh
+type removal struct {
+	built        bool
+	indexes      []int
+	combinations []Combination
+	sets         []CardSet
+	hand         CardSet
 }
 
 func NewPlayer() Player {
@@ -61,8 +85,6 @@ func NewPlayer() Player {
 		isBot:        false,
 		cards:        nil,
 		combinations: make([]Combination, 0),
-		connectors:   make(map[Combination][]Combination),
-		cardsLength:  13,
 	}
 }
 
@@ -71,47 +93,76 @@ func (l *LocalPlayer) GetOriginalCards() []*Card {
 }
 
 func (l *LocalPlayer) AllAvailableCombinations() []Combination {
+	l.build()
 	return l.combinations
 }
 
-func (l *LocalPlayer) AllAvailableCombinationsDefeat(combination Combination) []Combination {
-	combinations := []Combination{}
-	for i := 0; i < len(l.combinations); i++ {
-		if l.combinations[i].Defeats(combination) {
-			combinations = append(combinations, l.combinations[i])
-		}
-	}
-	return combinations
+func (l *LocalPlayer) AllAvailableCombinationsDefeat(combination Combination, rules RuleSet) []Combination {
+	return NewMoveGenerator(l.hand, combination, rules).All()
 }
 
 func (l *LocalPlayer) Remove(combination Combination) {
-	connector, ok := l.connectors[combination]
-	if !ok {
+	set := NewCardSet(combination.Cards()...)
+	if !l.hand.ContainsAll(set) {
 		panic("invalid input")
 	}
-	l.removeCombination(combination)
-	l.cardsLength -= len(combination.Cards())
-	if l.cardsLength < 0 {
-		panic("length of card can not be less than zero")
-	}
-	for _, c := range connector {
-		index := l.removeCombination(c)
-		if index >= 0 {
-			l.computeScore(c)
+	r := removal{built: l.built, hand: l.hand}
+	// chưa sinh các bộ bài thì chỉ cần cập nhật hand
+	// xóa mọi bộ có chung ít nhất 1 lá với combination, kể cả chính nó
+	for i := 0; l.built && i < len(l.combinations); {
+		if !l.sets[i].Overlaps(set) {
+			i++
+			continue
 		}
+		r.indexes = append(r.indexes, i)
+		r.combinations = append(r.combinations, l.combinations[i])
+		r.sets = append(r.sets, l.sets[i])
+		l.removeCombination(i)
+	}
+	l.hand = l.hand.Difference(set)
+	l.arrangement = nil
+	l.removals = append(l.removals, r)
+}
+
+func (l *LocalPlayer) UndoRemove() {
+	if len(l.removals) == 0 {
+		panic("nothing to undo")
+	}
+	r := l.removals[len(l.removals)-1]
+	// giới hạn capacity để append sau này không ghi đè lên bản copy dùng chung mảng
+	l.removals = l.removals[: len(l.removals)-1 : len(l.removals)-1]
+	l.hand = r.hand
+	l.arrangement = nil
+	if !r.built {
+		// các bộ bài được sinh sau lần Remove này từ hand nhỏ hơn, sinh lại khi cần
+		l.built = false
+		l.combinations = l.combinations[:0:0]
+		l.sets = nil
+		return
 	}
-	l.computeScore(combination)
-	if l.score < -0.00001 {
-		panic("score must be bigger than zero")
+	// removeCombination đưa bộ cuối vào vị trí bị xóa, làm ngược lại theo thứ tự ngược
+	for i := len(r.indexes) - 1; i >= 0; i-- {
+		index := r.indexes[i]
+		l.combinations = append(l.combinations, r.combinations[i])
+		l.sets = append(l.sets, r.sets[i])
+		last := len(l.combinations) - 1
+		l.combinations[index], l.combinations[last] = l.combinations[last], l.combinations[index]
+		l.sets[index], l.sets[last] = l.sets[last], l.sets[index]
 	}
 }
 
+// SetCards có thể được gọi lại sau Validate, các bộ bài sẽ được sinh lại từ bộ bài mới
 func (l *LocalPlayer) SetCards(cards []*Card) {
 	sort.Slice(cards, func(i, j int) bool {
 		return compareCard(cards[i], cards[j]) < 0
 	})
 	l.cards = cards
-	l.cardsLength = len(cards)
+	l.hand = NewCardSet(cards...)
+	l.built = false
+	l.combinations = l.combinations[:0:0]
+	l.sets = nil
+	l.arrangement = nil
+	l.removals = nil
 }
 
 func (l *LocalPlayer) WithCards(cards []*Card) Player {
@@ -127,65 +178,38 @@ func (l *LocalPlayer) Validate() {
 	if l.cards == nil {
 		panic("invalid Cards")
 	}
-	for _, card := range l.cards {
-		l.combinations = append(l.combinations, NewSingleCard(card))
-	}
-	dubs := GetDubs(l.cards)
-	for i := 0; i < len(dubs); i++ {
-		l.combinations = append(l.combinations, dubs[i])
-	}
-	sequences := GetSequence(l.cards)
-	for i := 0; i < len(sequences); i++ {
-		l.combinations = append(l.combinations, sequences[i])
-	}
-	twoPairs := GetTwoConsecutivePairs(l.cards)
-	for i := 0; i < len(twoPairs); i++ {
-		l.combinations = append(l.combinations, twoPairs[i])
-	}
-	trips := GetTrips(l.cards)
-	for i := 0; i < len(trips); i++ {
-		l.combinations = append(l.combinations, trips[i])
-	}
-	threePairs := GetThreeConsecutivePairs(l.cards)
-	for i := 0; i < len(threePairs); i++ {
-		l.combinations = append(l.combinations, threePairs[i])
-	}
-	quads := GetQuads(l.cards)
-	for i := 0; i < len(quads); i++ {
-		l.combinations = append(l.combinations, quads[i])
-	}
-	fourPairs := GetFourConsecutivePairs(l.cards)
-	for i := 0; i < len(fourPairs); i++ {
-		l.combinations = append(l.combinations, fourPairs[i])
-	}
-	for i := 0; i < len(l.combinations); i++ {
-		combination := l.combinations[i]
-		connector := []Combination{}
-		for j := 0; j < len(l.combinations); j++ {
-			if i != j && hasAtLeastSameOneCard(combination.Cards(), l.combinations[j].Cards()) {
-				connector = append(connector, l.combinations[j])
-			}
-		}
-		l.connectors[combination] = connector
-		l.computeScore(l.combinations[i])
+}
+
+// build sinh toàn bộ các bộ bài của hand bằng MoveGenerator, chỉ chạy một lần
+func (l *LocalPlayer) build() {
+	if l.built {
+		return
 	}
-	l.score = -l.score
-	sort.Slice(l.combinations, func(i, j int) bool {
+	l.built = true
+	l.combinations = append(l.combinations, NewMoveGenerator(l.hand, nil, nil).All()...)
+	sort.SliceStable(l.combinations, func(i, j int) bool {
 		return len(l.combinations[i].Cards()) < len(l.combinations[j].Cards())
 	})
+	l.sets = make([]CardSet, len(l.combinations))
+	for i := range l.combinations {
+		l.sets[i] = NewCardSet(l.combinations[i].Cards()...)
+	}
 }
 
 func (l *LocalPlayer) Copy() Player {
-	player :=  &LocalPlayer{
+	player := &LocalPlayer{
 		index:        l.index,
 		isBot:        l.isBot,
 		cards:        l.cards,
+		hand:         l.hand,
+		built:        l.built,
 		combinations: make([]Combination, len(l.combinations)),
-		connectors:   l.connectors,
-		cardsLength:  l.cardsLength,
-		score:        l.score,
+		sets:         make([]CardSet, len(l.sets)),
+		arrangement:  l.arrangement,
+		removals:     l.removals[:len(l.removals):len(l.removals)],
 	}
 	copy(player.combinations, l.combinations)
+	copy(player.sets, l.sets)
 	return player
 }
 
@@ -194,22 +218,20 @@ func (l *LocalPlayer) IsBot() bool {
 }
 
 func (l *LocalPlayer) SetBot(bot bool) {
-	l.isBot =  bot
+	l.isBot = bot
 }
 
 func (l *LocalPlayer) GetCardsLength() int {
-	return l.cardsLength
+	return l.hand.Len()
 }
 
-func (l *LocalPlayer) removeCombination(combination Combination) int {
-	for i := 0; i < len(l.combinations); i++ {
-		if l.combinations[i].Equals(combination) {
-			l.combinations[i] = l.combinations[len(l.combinations) - 1]
-			l.combinations = l.combinations[:len(l.combinations) - 1]
-			return i
-		}
-	}
-	return -1
+// removeCombination xóa bộ ở vị trí index, bộ cuối được đưa vào vị trí đó
+func (l *LocalPlayer) removeCombination(index int) {
+	last := len(l.combinations) - 1
+	l.combinations[index] = l.combinations[last]
+	l.combinations = l.combinations[:last]
+	l.sets[index] = l.sets[last]
+	l.sets = l.sets[:last]
 }
 
 func (l *LocalPlayer) GetSmallestCard() *Card {
@@ -217,42 +239,50 @@ func (l *LocalPlayer) GetSmallestCard() *Card {
 }
 
 func hasAtLeastSameOneCard(cards1, cards2 []*Card) bool {
-	for _, c1 := range cards1 {
-		for _, c2 := range cards2 {
-			if c1.equals(c2) {
-				return true
-			}
-		}
-	}
-	return false
+	return NewCardSet(cards1...).Overlaps(NewCardSet(cards2...))
 }
 
 func (l *LocalPlayer) GetScore() float64 {
-	return l.score
+	return l.GetArrangement().Score.Total()
 }
 
-func (l *LocalPlayer) GetAllCombinationsHasSameAtLeastOneCardWith(combination Combination) []Combination {
-	return l.connectors[combination]
+func (l *LocalPlayer) GetArrangement() *HandArrangement {
+	if l.arrangement == nil {
+		l.arrangement = arrangeHand(l.hand)
+	}
+	return l.arrangement
 }
 
-func (l *LocalPlayer) computeScore(combination Combination) {
-	switch combination.Kind() {
-	case CombinationSingle:
-		c := combination.(*SingleCard)
-		if c.card.rank == Two {
-			if c.card.suit < Diamond {
-				l.score -= FactorBlack2sCard
-			} else {
-				l.score -= FactorRed2sCard
-			}
-		} else {
-			l.score -= FactorNormalSingleCard
+func (l *LocalPlayer) GetAllCombinationsHasSameAtLeastOneCardWith(combination Combination) []Combination {
+	l.build()
+	set := NewCardSet(combination.Cards()...)
+	list := []Combination{}
+	for i := range l.combinations {
+		// mỗi tập lá chỉ tạo thành đúng một bộ, bỏ qua chính combination
+		if l.sets[i] != set && l.sets[i].Overlaps(set) {
+			list = append(list, l.combinations[i])
 		}
-	case CombinationThreeConsecutivePairs:
-		l.score -= FactorThreePairs
-	case CombinationQuads:
-		l.score -= FactorQuads
-	case CombinationFourConsecutivePairs:
-		l.score -= FactorFourPairs
 	}
-}
\ No newline at end of file
+	return list
+}
+
+func (l *LocalPlayer) GetCombination(combination Combination) Combination {
+	// không sinh các bộ bài, chỉ cần các lá nằm trong hand và tạo được bộ cùng loại
+	set := NewCardSet(combination.Cards()...)
+	if set.Len() != len(combination.Cards()) || !l.hand.ContainsAll(set) {
+		return nil
+	}
+	c, err := ParseCombination(set.Cards(), combination.Kind())
+	if err != nil {
+		return nil
+	}
+	return c
+}
+
+func (l *LocalPlayer) GetRemainingCards() []*Card {
+	return l.hand.Cards()
+}
+
+func (l *LocalPlayer) GetHand() CardSet {
+	return l.hand
+}
@@ -0,0 +1,155 @@
+package tienlen_bot
+
+// PlayerView is what one seat at the table is allowed to know about a game:
+// its own cards, how many cards every opponent holds, the last dealt combination,
+// who has passed, the moves played so far and the cards already seen.
+// Bots and heuristics working on a PlayerView can not peek at the opponents' hands.
+type PlayerView struct {
+	seat                  int
+	cards                 []*Card
+	combinations          []Combination
+	availableCombinations []Combination
+	cardsLength           []int
+	passed                []bool
+	currentPlayerIndex    int
+	previousPlayerIndex   int
+	lastDealtCombination  Combination
+	isFirstTurn           bool
+	history               []Turn
+	seenCards             []*Card
+	rules                 RuleSet
+	tracker               *CardTracker
+}
+
+func NewPlayerView(game Game, seat int) *PlayerView {
+	view := &PlayerView{
+		seat:                 seat,
+		cards:                game.GetPlayerAt(seat).GetRemainingCards(),
+		combinations:         game.GetPlayerAt(seat).AllAvailableCombinations(),
+		cardsLength:          make([]int, game.GetMaxPlayerNumber()),
+		passed:               make([]bool, game.GetMaxPlayerNumber()),
+		currentPlayerIndex:   game.GetCurrentPlayerIndex(),
+		previousPlayerIndex:  game.GetPreviousPlayerIndex(),
+		lastDealtCombination: game.GetLastDealtCombination(),
+		isFirstTurn:          game.IsFirstTurn(),
+		history:              game.GetPlayHistory(),
+		seenCards:            game.GetPlayedCards(),
+		rules:                game.GetRules(),
+	}
+	view.tracker = NewCardTracker(view.cards, view.seenCards, view.rules)
+	if seat == game.GetCurrentPlayerIndex() {
+		view.availableCombinations = game.AllAvailableCombinations()
+	}
+	for i := 0; i < game.GetMaxPlayerNumber(); i++ {
+		view.cardsLength[i] = game.GetPlayerAt(i).GetCardsLength()
+		view.passed[i] = game.PlayerPassed(i)
+	}
+	return view
+}
+
+// GetSeat returns the index of the player who owns the view
+func (v *PlayerView) GetSeat() int {
+	return v.seat
+}
+
+// GetCards returns the cards still in the seat's hand
+func (v *PlayerView) GetCards() []*Card {
+	return v.cards
+}
+
+// GetCombinations returns every combination that can be made from the seat's hand
+func (v *PlayerView) GetCombinations() []Combination {
+	return v.combinations
+}
+
+// GetArrangement returns the suggested arrangement of the seat's cards,
+// the split into the fewest combinations
+func (v *PlayerView) GetArrangement() *HandArrangement {
+	return ArrangeHand(v.cards)
+}
+
+// GetAllCombinationsHasSameAtLeastOneCardWith returns the seat's combinations sharing a card with combination
+func (v *PlayerView) GetAllCombinationsHasSameAtLeastOneCardWith(combination Combination) []Combination {
+	list := []Combination{}
+	for _, c := range v.combinations {
+		if !c.Equals(combination) && hasAtLeastSameOneCard(c.Cards(), combination.Cards()) {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// AllAvailableCombinations returns the combinations the seat can play now,
+// it is empty when it is not the seat's turn
+func (v *PlayerView) AllAvailableCombinations() []Combination {
+	return v.availableCombinations
+}
+
+func (v *PlayerView) GetCardsLength(index int) int {
+	return v.cardsLength[index]
+}
+
+func (v *PlayerView) GetMaxPlayerNumber() int {
+	return len(v.cardsLength)
+}
+
+func (v *PlayerView) GetCurrentPlayerIndex() int {
+	return v.currentPlayerIndex
+}
+
+func (v *PlayerView) GetPreviousPlayerIndex() int {
+	return v.previousPlayerIndex
+}
+
+func (v *PlayerView) GetLastDealtCombination() Combination {
+	return v.lastDealtCombination
+}
+
+func (v *PlayerView) HasNoLastDealtCombination() bool {
+	return isNil(v.lastDealtCombination)
+}
+
+func (v *PlayerView) PlayerPassed(index int) bool {
+	return v.passed[index]
+}
+
+func (v *PlayerView) IsFirstTurn() bool {
+	return v.isFirstTurn
+}
+
+// GetRules returns the rule set of the game
+func (v *PlayerView) GetRules() RuleSet {
+	return v.rules
+}
+
+// GetPlayHistory returns every move and pass played so far
+func (v *PlayerView) GetPlayHistory() []Turn {
+	return v.history
+}
+
+// GetSeenCards returns the cards already played on the table
+func (v *PlayerView) GetSeenCards() []*Card {
+	return v.seenCards
+}
+
+// GetTracker returns the card tracker of the seat, built from its cards and the played cards
+func (v *PlayerView) GetTracker() *CardTracker {
+	return v.tracker
+}
+
+// GetUnseenCards returns the cards that are neither in the seat's hand nor played yet,
+// these are the only cards the opponents can hold
+func (v *PlayerView) GetUnseenCards() []*Card {
+	return v.tracker.Unseen().Cards()
+}
+
+// CountUnseenRank returns the number of unseen cards with the given rank
+func (v *PlayerView) CountUnseenRank(rank Rank) int {
+	return v.tracker.CountUnseenRank(rank)
+}
+
+// CanUnseenCardsDefeat reports whether some combination made from the unseen cards
+// defeats the given combination
+func (v *PlayerView) CanUnseenCardsDefeat(combination Combination) bool {
+	return v.tracker.CanUnseenDefeat(combination)
+}
@@ -0,0 +1,248 @@
+package tienlen_bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Position is the state of a game at a turn, written on one line by String as 8 fields separated by spaces:
+//
+//	hands current previous passed last first played finished
+//
+// hands is the cards of every seat separated by '/', passed has one 0 or 1 for every seat,
+// last is "-" or the kind and the cards of the last dealt combination like "dubs:7♠,7♥",
+// first is 1 on the first turn, played is the cards already played in the order they were played
+// and finished is the finishing order of the seats which emptied their hand. A game played until
+// ranked ends with every seat in it, the last one still holding its cards.
+// Lists are separated by ',' and an empty list is "-", for example:
+//
+//	3♠,4♦,K♥/5♣,9♥/-/Q♠,2♥ 1 0 0010 single:7♦ 0 3♥,7♦ 2
+type Position struct {
+	Hands                [][]*Card
+	CurrentPlayerIndex   int
+	PreviousPlayerIndex  int
+	Passed               []bool
+	LastDealtCombination Combination
+	IsFirstTurn          bool
+	PlayedCards          []*Card
+	FinishingOrder       []int
+}
+
+// GetPosition returns the position of game
+func GetPosition(game Game) *Position {
+	position := &Position{
+		Hands:                make([][]*Card, game.GetMaxPlayerNumber()),
+		CurrentPlayerIndex:   game.GetCurrentPlayerIndex(),
+		PreviousPlayerIndex:  game.GetPreviousPlayerIndex(),
+		Passed:               make([]bool, game.GetMaxPlayerNumber()),
+		LastDealtCombination: game.GetLastDealtCombination(),
+		IsFirstTurn:          game.IsFirstTurn(),
+		PlayedCards:          game.GetPlayedCards(),
+		FinishingOrder:       game.GetFinishingOrder(),
+	}
+	for i := range position.Hands {
+		position.Hands[i] = game.GetPlayerAt(i).GetRemainingCards()
+		position.Passed[i] = game.PlayerPassed(i)
+	}
+	return position
+}
+
+// FormatPosition writes the position of game on one line, ParsePosition reads it back
+func FormatPosition(game Game) string {
+	return GetPosition(game).String()
+}
+
+func (p *Position) String() string {
+	hands := make([]string, len(p.Hands))
+	for i := range p.Hands {
+		hands[i] = formatCardList(p.Hands[i])
+	}
+	passed := ""
+	for _, b := range p.Passed {
+		passed += ifThen(b, "1", "0").(string)
+	}
+	finished := "-"
+	if len(p.FinishingOrder) > 0 {
+		list := make([]string, len(p.FinishingOrder))
+		for i := range p.FinishingOrder {
+			list[i] = strconv.Itoa(p.FinishingOrder[i])
+		}
+		finished = strings.Join(list, ",")
+	}
+	last := "-"
+	if notNil(p.LastDealtCombination) {
+		last = kindToken(p.LastDealtCombination.Kind()) + ":" + formatCardList(p.LastDealtCombination.Cards())
+	}
+	return strings.Join([]string{
+		strings.Join(hands, "/"),
+		strconv.Itoa(p.CurrentPlayerIndex),
+		strconv.Itoa(p.PreviousPlayerIndex),
+		passed,
+		last,
+		ifThen(p.IsFirstTurn, "1", "0").(string),
+		formatCardList(p.PlayedCards),
+		finished,
+	}, " ")
+}
+
+// ParsePosition reads a position written by Position.String,
+// it returns an error wrapping ErrInvalidPosition on invalid input
+func ParsePosition(s string) (*Position, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 8 {
+		return nil, fmt.Errorf("%w: %d fields instead of 8", ErrInvalidPosition, len(fields))
+	}
+	position := &Position{}
+	seen := []*Card{}
+	for _, hand := range strings.Split(fields[0], "/") {
+		cards, err := parseCardList(hand)
+		if err != nil {
+			return nil, err
+		}
+		position.Hands = append(position.Hands, SortCard(cards))
+		seen = append(seen, cards...)
+	}
+	seats := len(position.Hands)
+	if seats < 2 || seats > 4 {
+		return nil, fmt.Errorf("%w: %d seats", ErrInvalidPosition, seats)
+	}
+	var err error
+	if position.CurrentPlayerIndex, err = parseSeat(fields[1], seats); err != nil {
+		return nil, err
+	}
+	if position.PreviousPlayerIndex, err = parseSeat(fields[2], seats); err != nil {
+		return nil, err
+	}
+	if len(fields[3]) != seats || strings.Trim(fields[3], "01") != "" {
+		return nil, fmt.Errorf("%w: pass flags %s", ErrInvalidPosition, fields[3])
+	}
+	for _, c := range fields[3] {
+		position.Passed = append(position.Passed, c == '1')
+	}
+	if fields[4] != "-" {
+		if position.LastDealtCombination, err = parseCombinationToken(fields[4]); err != nil {
+			return nil, err
+		}
+	}
+	if fields[5] != "0" && fields[5] != "1" {
+		return nil, fmt.Errorf("%w: first turn flag %s", ErrInvalidPosition, fields[5])
+	}
+	position.IsFirstTurn = fields[5] == "1"
+	if position.PlayedCards, err = parseCardList(fields[6]); err != nil {
+		return nil, err
+	}
+	seen = append(seen, position.PlayedCards...)
+	if fields[7] != "-" {
+		for _, s := range strings.Split(fields[7], ",") {
+			seat, err := parseSeat(s, seats)
+			if err != nil {
+				return nil, err
+			}
+			position.FinishingOrder = append(position.FinishingOrder, seat)
+		}
+		// chỉ người về bét của một game xếp hạng hết được còn bài
+		for i, seat := range position.FinishingOrder {
+			last := i == seats-1 && len(position.FinishingOrder) == seats
+			if len(position.Hands[seat]) > 0 && !last {
+				return nil, fmt.Errorf("%w: seat %d finished with cards in hand", ErrInvalidPosition, seat)
+			}
+		}
+	}
+	for i := range seen {
+		for j := i + 1; j < len(seen); j++ {
+			if seen[i].equals(seen[j]) {
+				return nil, fmt.Errorf("%w: card %s is used twice", ErrInvalidPosition, seen[i])
+			}
+		}
+	}
+	return position, nil
+}
+
+// NewGameFromPosition creates a game at position, the other settings come from config.
+// Instant wins are never checked on the position, config keeps them for the games dealt later. The seats with no card left are finished and unless
+// config plays until ranked, the game is then ended and won by the first seat which finished.
+func NewGameFromPosition(config *GameConfiguration, position *Position) Game {
+	return newGameFromPosition(config, position, nil, true)
+}
+
+// newGameFromPosition giống NewGameFromPosition, bots[i] cho biết ghế i có phải bot không
+// và noInstantWins = false xét tới trắng nếu position là lúc vừa chia bài
+func newGameFromPosition(config *GameConfiguration, position *Position, bots []bool, noInstantWins bool) *LocalGame {
+	passed := make([]bool, len(position.Hands))
+	copy(passed, position.Passed)
+	game := newGameAt(config, &DealState{
+		CurrentPlayerIndex:   position.CurrentPlayerIndex,
+		PreviousPlayerIndex:  position.PreviousPlayerIndex,
+		Passed:               passed,
+		LastDealtCombination: position.LastDealtCombination,
+		IsFirstTurn:          position.IsFirstTurn,
+		PlayedCards:          position.PlayedCards,
+		FinishingOrder:       position.FinishingOrder,
+		NoInstantWins:        noInstantWins,
+	})
+	for i, hand := range position.Hands {
+		cards := make([]*Card, len(hand))
+		copy(cards, hand)
+		player := NewPlayer()
+		player.SetBot(i < len(bots) && bots[i])
+		game.AddPlayer(player.WithCards(cards))
+	}
+	return game
+}
+
+// kindToken là tên loại bộ không chứa dấu cách
+func kindToken(kind CombinationKind) string {
+	return strings.ReplaceAll(kind.String(), " ", "-")
+}
+
+func parseCombinationToken(s string) (Combination, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%w: last dealt combination %s", ErrInvalidPosition, s)
+	}
+	cards, err := parseCardList(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	for kind := CombinationSingle; kind < CombinationPass; kind++ {
+		if kindToken(kind) == parts[0] {
+			combination, err := ParseCombination(cards, kind)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidPosition, err)
+			}
+			return combination, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: combination kind %s", ErrInvalidPosition, parts[0])
+}
+
+func parseSeat(s string, seats int) (int, error) {
+	seat, err := strconv.Atoi(s)
+	if err != nil || seat < 0 || seat >= seats {
+		return 0, fmt.Errorf("%w: seat %s", ErrInvalidPosition, s)
+	}
+	return seat, nil
+}
+
+func formatCardList(cards []*Card) string {
+	if len(cards) == 0 {
+		return "-"
+	}
+	list := make([]string, len(cards))
+	for i := range cards {
+		list[i] = cards[i].String()
+	}
+	return strings.Join(list, ",")
+}
+
+func parseCardList(s string) ([]*Card, error) {
+	if s == "-" {
+		return []*Card{}, nil
+	}
+	cards, err := TryParseCards(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPosition, err)
+	}
+	return cards, nil
+}
@@ -0,0 +1,51 @@
+package tienlen_bot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPositionRoundTrip(t *testing.T) {
+	eachRandomGame(t, func(t *testing.T, game Game, states []string) {
+		for game.Undo() == nil {
+		}
+		for {
+			s := FormatPosition(game)
+			position, err := ParsePosition(s)
+			if err != nil {
+				t.Fatalf("%s: %v", s, err)
+			}
+			if got := position.String(); got != s {
+				t.Fatalf("parsed %s as %s", s, got)
+			}
+			created := NewGameFromPosition(game.GetConfig(), position)
+			if got := FormatPosition(created); got != s {
+				t.Fatalf("created %s at %s", got, s)
+			}
+			if created.IsEnd() != game.IsEnd() {
+				t.Fatalf("created game at %s ended %v, want %v", s, created.IsEnd(), game.IsEnd())
+			}
+			if game.Redo() != nil {
+				break
+			}
+		}
+	})
+}
+
+func TestParsePositionRejectsInvalidInput(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"3♠/4♠ 0 0 00 - 0 -",
+		"3♠ 0 0 0 - 0 - -",
+		"3♠/4♠ 2 0 00 - 0 - -",
+		"3♠/4♠ 0 0 01x - 0 - -",
+		"3♠/4♠ 0 0 00 dubs:3♠ 0 - -",
+		"3♠/3♠ 0 0 00 - 0 - -",
+		"3♠/4♠ 0 0 00 - 0 3♠ -",
+		"3♠/4♠ 0 0 00 - 0 - 0",
+	} {
+		if _, err := ParsePosition(s); !errors.Is(err, ErrInvalidPosition) {
+			t.Errorf("ParsePosition(%q) error %v, want %v", s, err, ErrInvalidPosition)
+		}
+	}
+}
@@ -38,20 +38,6 @@ func (q *Quads) Cards() []*Card {
 	return []*Card{q.card1, q.card2, q.card3, q.card4}
 }
 
-func (q *Quads) Defeats(combination Combination) bool {
-	switch combination.Kind() {
-	case CombinationQuads:
-		return q.rank > combination.(*Quads).rank
-	case CombinationThreeConsecutivePairs:
-		return true
-	case CombinationDubs:
-		return combination.(*Dubs).rank == Two
-	case CombinationSingle:
-		return combination.(*SingleCard).card.rank == Two
-	}
-	return false
-}
-
 func (q *Quads) Copy() Combination {
 	return NewQuads(q.card1, q.card2, q.card3, q.card4)
 }
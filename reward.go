@@ -10,6 +10,10 @@ const (
 	FactorThreePairs        float64 = 0.1 * Multiplier
 	FactorFourPairs         float64 = 0.3 * Multiplier
 	FactorQuads             float64 = 0.2 * Multiplier
+	FactorInstantWin        float64 = 0.5 * Multiplier
+	FactorChopPoint         float64 = 0.01 * Multiplier
+	FactorPlay              float64 = 0.02 * Multiplier
+	FactorLeftover          float64 = 0.01 * Multiplier
 )
 
 type Reward interface {
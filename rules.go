@@ -0,0 +1,242 @@
+package tienlen_bot
+
+// RuleSet decides which combinations can be played on top of others,
+// so tables of several regions can share one engine. It is selected with GameConfiguration.Rules.
+// Every rule set makes combinations the same way, 2s never being part of a sequence.
+type RuleSet interface {
+	Name() string
+	// Defeats reports whether combination can be dealt on top of target
+	Defeats(combination, target Combination) bool
+}
+
+// luật mặc định khi không chọn luật
+var defaultRules = NewCambodianRules()
+
+// ruleSetByName trả về luật có sẵn theo tên, nil nếu không có
+func ruleSetByName(name string) RuleSet {
+	for _, rules := range []RuleSet{NewCambodianRules(), NewSouthernRules(), NewNorthernRules()} {
+		if rules.Name() == name {
+			return rules
+		}
+	}
+	return nil
+}
+
+// CambodianRules is the rule set the bot was written for: cards below Six of the same rank
+// defeat each other whatever their suits, and only a same suit sequence defeats a same suit sequence.
+type CambodianRules struct{}
+
+func NewCambodianRules() RuleSet {
+	return &CambodianRules{}
+}
+
+func (r *CambodianRules) Name() string {
+	return "cambodian"
+}
+
+func (r *CambodianRules) Defeats(combination, target Combination) bool {
+	if defeats, ok := chops(combination, target); ok {
+		return defeats
+	}
+	if combination.Kind() != target.Kind() {
+		return false
+	}
+	switch c := combination.(type) {
+	case *SingleCard:
+		t := target.(*SingleCard)
+		if c.card.rank != t.card.rank {
+			return c.card.rank > t.card.rank
+		}
+		if c.card.rank >= Six {
+			return c.card.suit > t.card.suit
+		}
+		return true
+	case *Dubs:
+		t := target.(*Dubs)
+		if c.rank != t.rank {
+			return c.rank > t.rank
+		}
+		if c.rank >= Six {
+			return c.maxSuit > t.maxSuit
+		}
+		return true
+	case *Trips:
+		return c.rank > target.(*Trips).rank
+	case *Sequence:
+		t := target.(*Sequence)
+		if len(c.cardList) != len(t.cardList) {
+			return false
+		}
+		if !c.homogeneity && t.homogeneity {
+			return false
+		}
+		if c.minRank != t.minRank {
+			return c.minRank > t.minRank
+		}
+		if c.maxRank >= Six {
+			return c.suit > t.suit
+		}
+		return true
+	case *TwoConsecutivePairs:
+		return defeatsTwoConsecutivePairs(c, target.(*TwoConsecutivePairs))
+	}
+	return false
+}
+
+// SouthernRules is the Southern Vietnamese Tiến Lên: cards of the same rank are always
+// compared by suit and a sequence is defeated by a higher sequence of the same length.
+type SouthernRules struct{}
+
+func NewSouthernRules() RuleSet {
+	return &SouthernRules{}
+}
+
+func (r *SouthernRules) Name() string {
+	return "southern"
+}
+
+func (r *SouthernRules) Defeats(combination, target Combination) bool {
+	if defeats, ok := chops(combination, target); ok {
+		return defeats
+	}
+	if combination.Kind() != target.Kind() {
+		return false
+	}
+	switch c := combination.(type) {
+	case *SingleCard:
+		return compareCard(c.card, target.(*SingleCard).card) > 0
+	case *Dubs:
+		t := target.(*Dubs)
+		if c.rank != t.rank {
+			return c.rank > t.rank
+		}
+		return c.maxSuit > t.maxSuit
+	case *Trips:
+		return c.rank > target.(*Trips).rank
+	case *Sequence:
+		t := target.(*Sequence)
+		if len(c.cardList) != len(t.cardList) {
+			return false
+		}
+		if c.maxRank != t.maxRank {
+			return c.maxRank > t.maxRank
+		}
+		return c.suit > t.suit
+	case *TwoConsecutivePairs:
+		return defeatsTwoConsecutivePairs(c, target.(*TwoConsecutivePairs))
+	}
+	return false
+}
+
+// NorthernRules is the Northern Tiến Lên: it is the Southern rule set but a play must follow
+// the target, a single card the same suit, dubs the same colors and a sequence the same suits.
+// A Two can be dealt on any lower single card.
+type NorthernRules struct {
+	southern SouthernRules
+}
+
+func NewNorthernRules() RuleSet {
+	return &NorthernRules{}
+}
+
+func (r *NorthernRules) Name() string {
+	return "northern"
+}
+
+func (r *NorthernRules) Defeats(combination, target Combination) bool {
+	if defeats, ok := chops(combination, target); ok {
+		return defeats
+	}
+	return followsSuit(combination, target) && r.southern.Defeats(combination, target)
+}
+
+// followsSuit kiểm tra bộ combination có theo chất / màu của bộ target (luật miền Bắc)
+func followsSuit(combination, target Combination) bool {
+	if combination.Kind() != target.Kind() {
+		return true
+	}
+	switch c := combination.(type) {
+	case *SingleCard:
+		t := target.(*SingleCard)
+		return c.card.suit == t.card.suit || (c.card.rank == Two && t.card.rank != Two)
+	case *Dubs:
+		t := target.(*Dubs)
+		return countRedCards(c.Cards()) == countRedCards(t.Cards())
+	case *Sequence:
+		t := target.(*Sequence)
+		if len(c.cardList) != len(t.cardList) {
+			return true
+		}
+		for i := range c.cardList {
+			if c.cardList[i].suit != t.cardList[i].suit {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func countRedCards(cards []*Card) int {
+	count := 0
+	for _, card := range cards {
+		if card.suit == Diamond || card.suit == Heart {
+			count++
+		}
+	}
+	return count
+}
+
+// chops xử lý các hàng chặt (tứ quý, 3 đôi thông, 4 đôi thông) giống nhau ở mọi luật,
+// ok = false nếu combination không phải hàng chặt
+func chops(combination, target Combination) (defeats bool, ok bool) {
+	switch c := combination.(type) {
+	case *Quads:
+		switch target.Kind() {
+		case CombinationQuads:
+			return c.rank > target.(*Quads).rank, true
+		case CombinationThreeConsecutivePairs:
+			return true, true
+		case CombinationDubs:
+			return target.(*Dubs).rank == Two, true
+		case CombinationSingle:
+			return target.(*SingleCard).card.rank == Two, true
+		}
+		return false, true
+	case *ThreeConsecutivePairs:
+		switch target.Kind() {
+		case CombinationThreeConsecutivePairs:
+			t := target.(*ThreeConsecutivePairs)
+			if c.minRank == t.minRank {
+				return c.maxSuit > t.maxSuit, true
+			}
+			return c.minRank > t.minRank, true
+		case CombinationSingle:
+			return target.(*SingleCard).card.rank == Two, true
+		}
+		return false, true
+	case *FourConsecutivePairs:
+		switch target.Kind() {
+		case CombinationFourConsecutivePairs:
+			t := target.(*FourConsecutivePairs)
+			if c.minRank == t.minRank {
+				return c.maxSuit > t.maxSuit, true
+			}
+			return c.minRank > t.minRank, true
+		case CombinationQuads, CombinationThreeConsecutivePairs:
+			return true, true
+		case CombinationDubs:
+			return target.(*Dubs).rank == Two, true
+		case CombinationSingle:
+			return target.(*SingleCard).card.rank == Two, true
+		}
+		return false, true
+	}
+	return false, false
+}
+
+func defeatsTwoConsecutivePairs(c, target *TwoConsecutivePairs) bool {
+	if c.minRank == target.minRank {
+		return c.maxSuit > target.maxSuit
+	}
+	return c.minRank > target.minRank
+}
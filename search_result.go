@@ -0,0 +1,143 @@
+package tienlen_bot
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// tên các luật kinh nghiệm có thể chọn nước đi thay cho mcts
+const (
+	HeuristicNone                         = ""
+	HeuristicNoAvailableCombination       = "no available combination"
+	HeuristicOnlyOneCombination           = "only one combination"
+	HeuristicDefeatSingleCard             = "defeat single card"
+	HeuristicAllOtherPeopleHasOnlyOneCard = "all other people has only one card"
+	HeuristicSmallestPairsInPairsList     = "smallest pairs in pairs list"
+)
+
+// ChildResult is the statistic of one combination the current player can play,
+// merged over the trees of all workers
+type ChildResult struct {
+	Combination Combination
+	Visit       int
+	// MeanReward is the average reward of every seat after playing Combination
+	MeanReward []float64
+	UCT        float64
+}
+
+func (c *ChildResult) String() string {
+	return fmt.Sprintf("%-40s|%-20s|%-20s|%-30s", "Node:   "+c.Combination.String(),
+		fmt.Sprintf("Visit:  %d", c.Visit), fmt.Sprintf("UCT:  %.4f", c.UCT),
+		fmt.Sprintf("Reward: %.4f", c.MeanReward))
+}
+
+// SearchResult describes how the combination to play was chosen
+type SearchResult struct {
+	// Best is the combination to play
+	Best Combination
+	// Children lists every combination explored at the root
+	Children []*ChildResult
+	// PrincipalVariation is the most visited line of play starting with Best
+	PrincipalVariation []Combination
+	// Playouts is the number of mcts iterations over all workers
+	Playouts int
+	Elapsed  time.Duration
+	// Heuristic is the name of the rule which chose Best without searching, HeuristicNone otherwise
+	Heuristic  string
+	StopReason StopReason
+}
+
+func newHeuristicResult(combination Combination, heuristic string) *SearchResult {
+	return &SearchResult{
+		Best:       combination,
+		Heuristic:  heuristic,
+		StopReason: StopNone,
+	}
+}
+
+func (r *SearchResult) String() string {
+	if r.Heuristic != HeuristicNone {
+		return fmt.Sprintf("Heuristic %s: %s", r.Heuristic, r.Best)
+	}
+	variation := make([]string, len(r.PrincipalVariation))
+	for i := range r.PrincipalVariation {
+		variation[i] = r.PrincipalVariation[i].String()
+	}
+	s := fmt.Sprintf("MCTS %d playouts, thinking time: %d, stopped by %s, best: %s\n",
+		r.Playouts, r.Elapsed.Milliseconds(), r.StopReason, r.Best)
+	s += "Variation: " + strings.Join(variation, " -> ") + "\n"
+	for _, child := range r.Children {
+		s += child.String() + "\n"
+	}
+	return s
+}
+
+// mergeRootChildren cộng dồn số lượt thăm và reward của các node con cùng nước đi
+// trên tất cả các cây, giữ nguyên thứ tự xuất hiện
+func mergeRootChildren(roots []Node, playerIndex int, maxNumberOfPlayers int, config *MctsConfig) []*ChildResult {
+	children := []*ChildResult{}
+	rewards := []Reward{}
+	for _, root := range roots {
+	Loop:
+		for _, child := range root.GetChildren() {
+			for i := range children {
+				if children[i].Combination.Equals(child.GetCombination()) {
+					children[i].Visit += child.GetVisit()
+					rewards[i].AddReward(child.GetReward())
+					continue Loop
+				}
+			}
+			reward := NewReward(maxNumberOfPlayers)
+			reward.AddReward(child.GetReward())
+			rewards = append(rewards, reward)
+			children = append(children, &ChildResult{
+				Combination: child.GetCombination(),
+				Visit:       child.GetVisit(),
+			})
+		}
+	}
+	total := 0
+	for i := range children {
+		total += children[i].Visit
+	}
+	for i, child := range children {
+		child.MeanReward = make([]float64, maxNumberOfPlayers)
+		if child.Visit == 0 {
+			continue
+		}
+		for j := 0; j < maxNumberOfPlayers; j++ {
+			child.MeanReward[j] = rewards[i].GetScoreOfPlayer(j) / float64(child.Visit)
+		}
+		child.UCT = child.MeanReward[playerIndex] +
+			config.C*math.Sqrt(math.Log(float64(total))/float64(child.Visit)) +
+			config.K/(config.K+float64(child.Visit))
+	}
+	return children
+}
+
+// principalVariation follows the most visited children, starting from the root child
+// playing combination in the tree which visited it most
+func principalVariation(roots []Node, combination Combination) []Combination {
+	var node Node
+	for _, root := range roots {
+		for _, child := range root.GetChildren() {
+			if child.GetCombination().Equals(combination) && (isNil(node) || child.GetVisit() > node.GetVisit()) {
+				node = child
+			}
+		}
+	}
+	variation := []Combination{}
+	for notNil(node) {
+		variation = append(variation, node.GetCombination())
+		var next Node
+		for _, child := range node.GetChildren() {
+			if child.GetVisit() > 0 && (isNil(next) || child.GetVisit() > next.GetVisit()) {
+				next = child
+			}
+		}
+		node = next
+	}
+	return variation
+}
@@ -0,0 +1,94 @@
+package tienlen_bot
+
+import (
+	"context"
+	"time"
+)
+
+// Searcher searches the moves of one bot during a game and keeps its trees between
+// consecutive searches. Before each search the old trees are re-rooted by following
+// the moves played since the last search, so the statistics gathered for them are kept.
+// A Searcher must not be used by several goroutines at the same time.
+type Searcher struct {
+	config         *MctsConfig
+	roots          []Node
+	history        []Turn
+	informationSet bool
+}
+
+func NewSearcher(config *MctsConfig) *Searcher {
+	return &Searcher{
+		config: config,
+	}
+}
+
+// Search works like the Search function but reuses the trees of the previous search
+// when game continues the game searched last time
+func (s *Searcher) Search(ctx context.Context, game Game) *SearchResult {
+	s.reroot(game)
+	startThinkingTime := time.Now()
+	result := selectBestCombination(ctx, game, s.config, s.roots)
+	result.Elapsed = time.Since(startThinkingTime)
+	if s.config.Debug {
+		println(result.String())
+	}
+	return result
+}
+
+// Reset drops the trees, the next search starts from scratch
+func (s *Searcher) Reset() {
+	s.roots = nil
+	s.history = nil
+}
+
+// reroot moves every root down the moves played since the last search,
+// a root is dropped when the move is not in its tree or the game is not the same.
+// A reused root is pruned like a new one, so it searches the same moves as Search
+func (s *Searcher) reroot(game Game) {
+	workers := s.config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	history := game.GetPlayHistory()
+	if len(s.roots) != workers || s.informationSet != s.config.InformationSet || !s.continues(history) {
+		s.roots = make([]Node, workers)
+	}
+	for i := range s.roots {
+		for j := len(s.history); j < len(history) && notNil(s.roots[i]); j++ {
+			s.roots[i] = childOf(s.roots[i], history[j].Combination)
+		}
+		if notNil(s.roots[i]) {
+			s.roots[i].Detach()
+			// node con được tạo khi còn có parent nên chưa qua các luật tỉa chỉ dành cho root
+			if node, ok := s.roots[i].(*LocalNode); ok {
+				node.pruneAsRoot(game)
+			}
+		}
+	}
+	s.history = history
+	s.informationSet = s.config.InformationSet
+}
+
+// continues reports whether history starts with the history of the last search
+func (s *Searcher) continues(history []Turn) bool {
+	if len(history) < len(s.history) {
+		return false
+	}
+	for i := range s.history {
+		if s.history[i].PlayerIndex != history[i].PlayerIndex ||
+			!s.history[i].Combination.Equals(history[i].Combination) {
+			return false
+		}
+	}
+	return true
+}
+
+// childOf trả về node con ứng với nước đi combination, nil nếu cây chưa có nước đi này
+func childOf(node Node, combination Combination) Node {
+	for _, child := range node.GetChildren() {
+		if child.GetCombination().Equals(combination) {
+			return child
+		}
+	}
+	return nil
+}
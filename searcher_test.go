@@ -0,0 +1,101 @@
+package tienlen_bot
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSearcherPrunesRerootedTreeLikeANewRoot(t *testing.T) {
+	position, err := ParsePosition("3♠,8♦/5♣,K♥ 0 0 00 - 0 - -")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := NewDefaultGameConfig(2)
+	config.Seed = 1
+	game := NewGameFromPosition(config, position)
+	root := NewNode(nil, nil, -1, game)
+	for len(root.(*LocalNode).unexploredCombinations) > 0 {
+		root.Expand(game.Copy())
+	}
+	// under the root, the answer to a single keeps the pass
+	cards, _ := TryParseCards("3♠")
+	lead, err := ParseCombination(cards, CombinationSingle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := childOf(root, lead).(*LocalNode)
+	if !containsKind(child.unexploredCombinations, CombinationPass) {
+		t.Fatalf("child moves %v: want a pass before re-rooting", child.unexploredCombinations)
+	}
+
+	searcher := NewSearcher(NewDefaultMctsConfig())
+	searcher.roots = []Node{root}
+	searcher.history = game.GetPlayHistory()
+	game.Move(lead)
+	searcher.reroot(game)
+
+	if searcher.roots[0] != Node(child) {
+		t.Fatal("the tree was not reused")
+	}
+	fresh := NewNode(nil, nil, -1, game).(*LocalNode)
+	moves := append([]Combination{}, child.unexploredCombinations...)
+	for _, node := range child.GetChildren() {
+		moves = append(moves, node.GetCombination())
+	}
+	if containsKind(moves, CombinationPass) || len(moves) != len(fresh.unexploredCombinations) {
+		t.Errorf("re-rooted moves %v, new root moves %v", moves, fresh.unexploredCombinations)
+	}
+}
+
+func containsKind(list []Combination, kind CombinationKind) bool {
+	for _, combination := range list {
+		if combination.Kind() == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSameSeedMakesTheSameDecisions(t *testing.T) {
+	for _, informationSet := range []bool{false, true} {
+		for seed := int64(1); seed <= 3; seed++ {
+			first, second := playSearchedGame(seed, informationSet), playSearchedGame(seed, informationSet)
+			if len(first) != len(second) {
+				t.Fatalf("seed %d information set %v: %d decisions then %d", seed, informationSet, len(first), len(second))
+			}
+			for i := range first {
+				if first[i] != second[i] {
+					t.Fatalf("seed %d information set %v decision %d:\n%s\nthen\n%s", seed, informationSet, i, first[i], second[i])
+				}
+			}
+		}
+	}
+}
+
+// playSearchedGame chơi một game 2 người với mỗi ghế một Searcher và trả về các kết quả tìm kiếm
+func playSearchedGame(seed int64, informationSet bool) []string {
+	game := newSeededGame(seed, 2, false)
+	searchers := make([]*Searcher, 2)
+	for i := range searchers {
+		config := NewDefaultMctsConfig()
+		config.Seed = seed + int64(i)*100
+		config.Interactions = 100
+		config.Workers = 2
+		config.MinThinkingTime = 0
+		config.MaxThinkingTime = 60000
+		config.InformationSet = informationSet
+		config.HandInference = informationSet
+		searchers[i] = NewSearcher(config)
+	}
+	decisions := []string{}
+	for !game.IsEnd() {
+		result := searchers[game.GetCurrentPlayerIndex()].Search(context.Background(), game)
+		decision := result.Best.String() + " " + result.Heuristic
+		for _, child := range result.Children {
+			decision += "\n" + child.String()
+		}
+		decisions = append(decisions, decision)
+		game.Move(result.Best)
+	}
+	return decisions
+}
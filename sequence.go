@@ -45,29 +45,6 @@ func (s *Sequence) Cards() []*Card {
 	return s.cardList
 }
 
-func (s *Sequence) Defeats(combination Combination) bool {
-	if combination.Kind() != CombinationSequence{
-		return false
-	}
-	sequence := combination.(*Sequence)
-	if len(s.cardList) != len(sequence.cardList) {
-		return false
-	}
-	if !s.homogeneity && sequence.homogeneity {
-		return false
-	}
-	if s.minRank > sequence.minRank {
-		return true
-	}
-	if s.minRank < sequence.minRank {
-		return false
-	}
-	if s.maxRank >= Six {
-		return s.suit > sequence.suit
-	}
-	return true
-}
-
 func (s *Sequence) Copy() Combination {
 	return NewSequence(s.cardList)
 }
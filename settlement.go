@@ -0,0 +1,105 @@
+package tienlen_bot
+
+// PenaltyTable is how many points a loser pays to the winner when a hand ends
+type PenaltyTable struct {
+	// PerCard is paid for every card left in hand
+	PerCard int `json:"perCard"`
+	// Black2 and Red2 are paid for every 2 left in hand (thối 2)
+	Black2 int `json:"black2"`
+	Red2   int `json:"red2"`
+	// ThreeConsecutivePairs, FourConsecutivePairs and Quads are paid for every such combination left in hand
+	ThreeConsecutivePairs int `json:"threeConsecutivePairs"`
+	FourConsecutivePairs  int `json:"fourConsecutivePairs"`
+	Quads                 int `json:"quads"`
+	// FrozenMultiplier multiplies the penalty of a player who never dealt a card (cóng)
+	FrozenMultiplier int `json:"frozenMultiplier"`
+}
+
+func NewDefaultPenaltyTable() *PenaltyTable {
+	return &PenaltyTable{
+		PerCard:               1,
+		Black2:                5,
+		Red2:                  10,
+		ThreeConsecutivePairs: 10,
+		FourConsecutivePairs:  20,
+		Quads:                 15,
+		FrozenMultiplier:      2,
+	}
+}
+
+// Settle returns the points won or lost by every seat of an ended game:
+// each loser pays its penalty to the winner, and the chops of the game are added.
+// A nil table means NewDefaultPenaltyTable. A seat is frozen when it still holds its 13 cards,
+// nobody is frozen when the game was won at deal time.
+// The amounts of the chops were computed during the game with GameConfiguration.Penalties.
+func Settle(game Game, table *PenaltyTable) ([]int, error) {
+	if !game.IsEnd() {
+		return nil, ErrGameNotEnded
+	}
+	if table == nil {
+		table = NewDefaultPenaltyTable()
+	}
+	// người còn ít hơn 13 lá đã đánh ít nhất một bộ, kể cả trước khi game được tạo từ một position
+	dealt := make([]bool, game.GetMaxPlayerNumber())
+	for i := range dealt {
+		dealt[i] = game.GetPlayerAt(i).GetCardsLength() < 13
+	}
+	winner := game.GetWinnerIndex()
+	deltas := chopScores(game.GetChopEvents(), game.GetMaxPlayerNumber())
+	for i := range deltas {
+		if i == winner {
+			continue
+		}
+		penalty := table.Penalty(game.GetPlayerAt(i).GetRemainingCards())
+		if !dealt[i] && game.GetInstantWin() == InstantWinNone {
+			penalty *= table.FrozenMultiplier
+		}
+		deltas[i] -= penalty
+		deltas[winner] += penalty
+	}
+	return deltas, nil
+}
+
+// Penalty returns the points a loser holding cards pays, without the frozen multiplier
+func (t *PenaltyTable) Penalty(cards []*Card) int {
+	penalty := len(cards) * t.PerCard
+	count := make([]int, 13)
+	for _, card := range cards {
+		count[card.rank]++
+		if card.rank == Two {
+			penalty += ifThen(card.suit < Diamond, t.Black2, t.Red2).(int)
+		}
+	}
+	// tứ quý không được tính lại trong đôi thông
+	for rank := Three; rank <= Two; rank++ {
+		if count[rank] == 4 {
+			penalty += t.Quads
+			count[rank] = 0
+		}
+	}
+	length := 0
+	for rank := Three; rank <= Two; rank++ {
+		if rank != Two && count[rank] >= 2 {
+			length++
+			continue
+		}
+		penalty += t.consecutivePairsPenalty(length)
+		length = 0
+	}
+	return penalty
+}
+
+// consecutivePairsPenalty tính phạt lớn nhất khi tách length đôi liên tiếp thành 3 đôi thông và 4 đôi thông
+func (t *PenaltyTable) consecutivePairsPenalty(length int) int {
+	best := make([]int, length+1)
+	for i := 1; i <= length; i++ {
+		best[i] = best[i-1]
+		if i >= 3 && best[i-3]+t.ThreeConsecutivePairs > best[i] {
+			best[i] = best[i-3] + t.ThreeConsecutivePairs
+		}
+		if i >= 4 && best[i-4]+t.FourConsecutivePairs > best[i] {
+			best[i] = best[i-4] + t.FourConsecutivePairs
+		}
+	}
+	return best[length]
+}
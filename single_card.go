@@ -20,23 +20,6 @@ func (s *SingleCard) Cards() []*Card {
 	return []*Card{s.card}
 }
 
-func (s *SingleCard) Defeats(combination Combination) bool {
-	if combination.Kind() != CombinationSingle {
-		return false
-	}
-	c := combination.(*SingleCard)
-	if c.card.rank > s.card.rank {
-		return false
-	}
-	if c.card.rank < s.card.rank {
-		return true
-	}
-	if c.card.rank >= Six {
-		return s.card.suit > c.card.suit
-	}
-	return true
-}
-
 func (s *SingleCard) Copy() Combination {
 	return NewSingleCard(&Card{
 		rank: s.card.rank,
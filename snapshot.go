@@ -0,0 +1,116 @@
+package tienlen_bot
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SnapshotVersion is the version of the snapshots written by NewGameSnapshot
+const SnapshotVersion = 1
+
+// GameSnapshot is a game written as the configuration and the position it started from
+// and the moves played since. Restore replays the moves, so the players and their
+// combinations are rebuilt exactly as they were. The random source of the restored game
+// starts again from the configuration's seed.
+type GameSnapshot struct {
+	Version int                `json:"version"`
+	Config  *GameConfiguration `json:"config"`
+	// Start is the starting position written with the position notation
+	Start string `json:"start"`
+	Bots  []bool `json:"bots"`
+	Turns []Turn `json:"turns"`
+	// NoInstantWins is true when instant wins were not checked on the deal of Start,
+	// as for a game created from a position
+	NoInstantWins bool `json:"noInstantWins,omitempty"`
+}
+
+func NewGameSnapshot(game Game) *GameSnapshot {
+	config := game.GetConfig()
+	turns := game.GetPlayHistory()
+	start := &Position{
+		Hands:                make([][]*Card, game.GetMaxPlayerNumber()),
+		CurrentPlayerIndex:   config.CurrentPlayerIndex,
+		PreviousPlayerIndex:  config.PreviousPlayerIndex,
+		Passed:               make([]bool, game.GetMaxPlayerNumber()),
+		LastDealtCombination: config.LastDealtCombination,
+		IsFirstTurn:          config.IsFirstTurn,
+	}
+	copy(start.Passed, config.Passed)
+	bots := make([]bool, game.GetMaxPlayerNumber())
+	for i := range start.Hands {
+		start.Hands[i] = game.GetPlayerAt(i).GetOriginalCards()
+		bots[i] = game.GetPlayerAt(i).IsBot()
+	}
+	// các lá đã đánh và người đã về trước khi game bắt đầu, với game tạo từ một position
+	played := len(game.GetPlayedCards())
+	for _, turn := range turns {
+		played -= len(turn.Combination.Cards())
+	}
+	start.PlayedCards = game.GetPlayedCards()[:played]
+	for _, index := range game.GetFinishingOrder() {
+		if len(start.Hands[index]) == 0 {
+			start.FinishingOrder = append(start.FinishingOrder, index)
+		}
+	}
+	return &GameSnapshot{
+		Version:       SnapshotVersion,
+		Config:        config,
+		Start:         start.String(),
+		Bots:          bots,
+		Turns:         turns,
+		NoInstantWins: noInstantWins(game),
+	}
+}
+
+// Restore rebuilds the game of the snapshot, it returns an error wrapping ErrInvalidSnapshot
+// when the snapshot has another version or its moves can not be replayed
+func (s *GameSnapshot) Restore() (Game, error) {
+	if s.Version != SnapshotVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrInvalidSnapshot, s.Version)
+	}
+	if s.Config == nil {
+		return nil, fmt.Errorf("%w: no configuration", ErrInvalidSnapshot)
+	}
+	position, err := ParsePosition(s.Start)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	// đặt bot trước khi chia bài để các sự kiện chia bài ghi đúng,
+	// tới trắng được xét lại như lúc chia bài của game gốc
+	game := newGameFromPosition(s.Config, position, s.Bots, s.NoInstantWins)
+	for i, turn := range s.Turns {
+		if err := game.TryMoveAt(turn.PlayerIndex, turn.Combination); err != nil {
+			return nil, fmt.Errorf("%w: turn %d: %v", ErrInvalidSnapshot, i, err)
+		}
+	}
+	return game, nil
+}
+
+// noInstantWins trả về true nếu game không xét tới trắng khi chia bài
+func noInstantWins(game Game) bool {
+	for _, event := range game.GetEvents() {
+		if event.Kind == EventDeal && event.Deal != nil {
+			return event.Deal.NoInstantWins
+		}
+	}
+	return false
+}
+
+// MarshalJSON writes the game as a GameSnapshot
+func (l *LocalGame) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewGameSnapshot(l))
+}
+
+// UnmarshalJSON restores a game written by MarshalJSON
+func (l *LocalGame) UnmarshalJSON(data []byte) error {
+	var snapshot GameSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+	game, err := snapshot.Restore()
+	if err != nil {
+		return err
+	}
+	*l = *game.(*LocalGame)
+	return nil
+}
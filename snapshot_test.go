@@ -0,0 +1,70 @@
+package tienlen_bot
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestSnapshotRestoresTheGame(t *testing.T) {
+	eachRandomGame(t, func(t *testing.T, game Game, states []string) {
+		// giữa game và lúc hết game
+		for _, undo := range []int{(len(states) - 1) / 2, 0} {
+			for i := 0; i < undo; i++ {
+				if err := game.Undo(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want := describeGame(game)
+			data, err := json.Marshal(game)
+			if err != nil {
+				t.Fatal(err)
+			}
+			restored := &LocalGame{}
+			if err := json.Unmarshal(data, restored); err != nil {
+				t.Fatalf("%s: %v", data, err)
+			}
+			if got := describeGame(restored); got != want {
+				t.Fatalf("restored %s, want %s", got, want)
+			}
+			for i := 0; i < game.GetMaxPlayerNumber(); i++ {
+				if restored.GetPlayerAt(i).IsBot() != game.GetPlayerAt(i).IsBot() {
+					t.Fatalf("seat %d restored with bot %v", i, restored.GetPlayerAt(i).IsBot())
+				}
+			}
+			for i := 0; i < undo; i++ {
+				if err := game.Redo(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+func TestSnapshotKeepsAnInstantWin(t *testing.T) {
+	position, err := ParsePosition("2♠,2♣,2♦,2♥,3♠,4♠,5♠,6♠,7♠,8♠,9♠,10♠,J♠/3♣,4♣,5♣,6♣,7♣,8♣,9♣,10♣,J♣,Q♣,K♣,A♣,Q♠ 0 0 00 - 1 - -")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := NewDefaultGameConfig(2)
+	config.InstantWins = AllInstantWinPatterns()
+	game := newGameFromPosition(config, position, nil, false)
+	if game.GetInstantWin() == InstantWinNone || !game.IsEnd() {
+		t.Fatalf("no instant win at deal: %s", describeGame(game))
+	}
+	restored, err := NewGameSnapshot(game).Restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := describeGame(restored), describeGame(game); got != want || !restored.IsEnd() {
+		t.Fatalf("restored %s, want %s", got, want)
+	}
+}
+
+func TestSnapshotRejectsAnotherVersion(t *testing.T) {
+	snapshot := NewGameSnapshot(newSeededGame(1, 4, false))
+	snapshot.Version++
+	if _, err := snapshot.Restore(); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("error %v, want %v", err, ErrInvalidSnapshot)
+	}
+}
@@ -43,21 +43,6 @@ func (t *ThreeConsecutivePairs) Cards() []*Card {
 	}
 }
 
-func (t *ThreeConsecutivePairs) Defeats(combination Combination) bool {
-	if combination.Kind() == CombinationThreeConsecutivePairs{
-		o := combination.(*ThreeConsecutivePairs)
-		if t.minRank == o.minRank {
-			return t.maxSuit > o.maxSuit
-		}
-		return t.minRank > o.minRank
-	}
-	if combination.Kind() == CombinationSingle {
-		o := combination.(*SingleCard)
-		return o.card.rank == Two
-	}
-	return false
-}
-
 func (t *ThreeConsecutivePairs) Copy() Combination {
 	return NewThreeConsecutivePairs(t.dubs1.Copy().(*Dubs),
 		t.dubs2.Copy().(*Dubs),
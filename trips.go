@@ -37,14 +37,6 @@ func (t *Trips) Cards() []*Card {
 	return []*Card{t.card1, t.card2, t.card3}
 }
 
-func (t *Trips) Defeats(combination Combination) bool {
-	if combination.Kind() != CombinationTrips {
-		return false
-	}
-	trips := combination.(*Trips)
-	return t.rank > trips.rank
-}
-
 func (t *Trips) Copy() Combination {
 	return NewTrips(t.card1, t.card2, t.card3)
 }
@@ -37,17 +37,6 @@ func (t *TwoConsecutivePairs) Cards() []*Card {
 	return []*Card{t.dubs1.card1, t.dubs1.card2, t.dubs2.card1, t.dubs2.card2}
 }
 
-func (t *TwoConsecutivePairs) Defeats(combination Combination) bool {
-	if combination.Kind() != CombinationTwoConsecutivePairs {
-		return false
-	}
-	o := combination.(*TwoConsecutivePairs)
-	if t.minRank == o.minRank {
-		return t.maxSuit > o.maxSuit
-	}
-	return t.minRank > o.minRank
-}
-
 func (t *TwoConsecutivePairs) Copy() Combination {
 	return NewTwoConsecutivePairs(t.dubs1.Copy().(*Dubs), t.dubs2.Copy().(*Dubs))
 }
@@ -1,15 +1,9 @@
 package tienlen_bot
 
 import (
-	"math/rand"
 	"reflect"
-	"time"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
 func isDubs(card1, card2 *Card) bool {
 	return card1.rank == card2.rank
 }
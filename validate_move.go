@@ -0,0 +1,95 @@
+package tienlen_bot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateMove checks whether the current player of game can play cards.
+// The cards are classified into a combination, an empty list means a pass.
+// It returns the combination of the player's hand to give to Game.Move, or a *MoveError
+// whose Reason explains why the move is refused.
+func ValidateMove(game Game, cards []*Card) (Combination, error) {
+	playerIndex := game.GetCurrentPlayerIndex()
+	refuse := func(combination Combination, err error, reason string) (Combination, error) {
+		e := newMoveError(playerIndex, combination, err)
+		e.Reason = reason
+		return nil, e
+	}
+	if game.IsEnd() {
+		return refuse(nil, ErrGameEnded, "the game has ended")
+	}
+	isLeader := game.GetCurrentPlayerIndex() == game.GetPreviousPlayerIndex()
+	if len(cards) == 0 {
+		if isLeader {
+			return refuse(NewPass(), ErrLeaderCannotPass, "you lead this round and must play a combination")
+		}
+		return NewPass(), nil
+	}
+	player := game.GetCurrentPlayer()
+	hand := player.GetRemainingCards()
+	for _, card := range cards {
+		if !containsCard(hand, card) {
+			return refuse(nil, ErrCombinationNotInHand, fmt.Sprintf("%s is not in your hand", card))
+		}
+	}
+	combinations, err := ClassifyCards(cards)
+	if err != nil {
+		return refuse(nil, ErrInvalidCombination, fmt.Sprintf("%s do not form a combination", formatCards(cards)))
+	}
+	// lấy cách hiểu đầu tiên có trong tay người chơi
+	var own Combination
+	for _, combination := range combinations {
+		if own = player.GetCombination(combination); notNil(own) {
+			break
+		}
+	}
+	if isNil(own) {
+		return refuse(combinations[0], ErrCombinationNotInHand, fmt.Sprintf("%s is not in your hand", combinations[0]))
+	}
+	rules := game.GetRules()
+	if isLeader {
+		card := player.GetSmallestCard()
+		if game.IsFirstTurn() && card.rank == Three && card.suit == Spade && !containsCard(own.Cards(), card) {
+			return refuse(own, ErrFirstPlayMustContainThreeOfSpades, "the first play of the game must contain 3♠")
+		}
+		return own, nil
+	}
+	last := game.GetLastDealtCombination()
+	if notNil(last) && !rules.Defeats(own, last) {
+		return refuse(own, ErrDoesNotDefeat, describeDefeatFailure(rules, own, last))
+	}
+	return own, nil
+}
+
+// describeDefeatFailure giải thích vì sao combination không chặn được target
+func describeDefeatFailure(rules RuleSet, combination, target Combination) string {
+	if combination.Kind() != target.Kind() {
+		return fmt.Sprintf("%s cannot beat %s", combination.Kind(), target.Kind())
+	}
+	if _, ok := rules.(*NorthernRules); ok && !followsSuit(combination, target) {
+		return fmt.Sprintf("%s %s must follow the suits of %s", combination.Kind(),
+			formatCards(combination.Cards()), formatCards(target.Cards()))
+	}
+	if combination.Kind() == CombinationSequence {
+		sequence := combination.(*Sequence)
+		targetSequence := target.(*Sequence)
+		if len(sequence.cardList) != len(targetSequence.cardList) {
+			return fmt.Sprintf("sequence length %d cannot beat sequence length %d",
+				len(sequence.cardList), len(targetSequence.cardList))
+		}
+		if _, ok := rules.(*CambodianRules); ok && !sequence.homogeneity && targetSequence.homogeneity {
+			return "a mixed suit sequence cannot beat a same suit sequence"
+		}
+	}
+	return fmt.Sprintf("%s %s cannot beat %s %s", combination.Kind(), formatCards(combination.Cards()),
+		target.Kind(), formatCards(target.Cards()))
+}
+
+func formatCards(cards []*Card) string {
+	list := make([]string, len(cards))
+	for i := range cards {
+		list[i] = cards[i].String()
+	}
+	return strings.Join(list, " ")
+}